@@ -1,7 +1,14 @@
 package auth
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/sts/types"
 	"github.com/walkersumida/aws-sso-google/credential"
+	"github.com/walkersumida/aws-sso-google/lock"
 	"github.com/walkersumida/aws-sso-google/saml"
 	"github.com/walkersumida/aws-sso-google/sts"
 )
@@ -10,6 +17,26 @@ type Auth struct {
 	Credential credential.Credentialer
 	SAML       saml.SAMLer
 	STS        sts.STSer
+
+	// SelectRole chooses the role to assume from the roles in the SAML
+	// assertion when no role arn is given. It is optional.
+	SelectRole func(roles []saml.Role) (*saml.Role, error)
+
+	// Locker serializes the signin across processes so that only one of
+	// them opens the browser. It is optional.
+	Locker lock.Locker
+
+	// Chain is the roles assumed one after another from the SAML session.
+	// Credential holds the credentials of the last hop.
+	Chain []Hop
+}
+
+// Hop is a role in a role chain.
+// Source caches the credentials the role is assumed with, that is the SAML
+// session for the first hop and the previous hop's role for the others.
+type Hop struct {
+	Role   sts.Hop
+	Source credential.Credentialer
 }
 
 func New(cred credential.Credentialer, saml saml.SAMLer, sts sts.STSer) *Auth {
@@ -21,35 +48,48 @@ func New(cred credential.Credentialer, saml saml.SAMLer, sts sts.STSer) *Auth {
 }
 
 func (a *Auth) SAMLAuth() (string, error) {
+	return a.SAMLAuthContext(context.Background())
+}
+
+// SAMLAuthContext is SAMLAuth that gives up waiting for the lock,
+// the signin and STS when ctx is done.
+func (a *Auth) SAMLAuthContext(ctx context.Context) (string, error) {
 	if err := a.Credential.Load(); err != nil {
 		return "", err
 	}
 	if !a.Credential.IsExpired() {
-		out, err := a.Credential.Output()
-		if err != nil {
-			return "", err
+		return a.Credential.Output()
+	}
+
+	if a.Locker != nil {
+		if err := a.Locker.LockContext(ctx); err != nil {
+			return "", fmt.Errorf("could not acquire lock: %w", err)
 		}
+		defer func() { _ = a.Locker.Unlock() }()
 
-		return out, nil
+		// Another process may have refreshed the credentials while waiting.
+		if err := a.Credential.Load(); err != nil {
+			return "", err
+		}
+		if !a.Credential.IsExpired() {
+			return a.Credential.Output()
+		}
 	}
 
-	samlRes, err := a.SAML.Signin()
+	// Resume the chain from the last hop whose source is still valid.
+	start, err := resumableHop(a.Chain)
 	if err != nil {
 		return "", err
 	}
 
-	a.STS.SetAwsPrincipalArn(samlRes.PrincipalArn)
-	a.STS.SetSAMLAssertion(samlRes.SAMLResponse)
-	stsRes, err := a.STS.AssumeRoleWithSAML()
-	if err != nil {
-		return "", err
+	if start < 0 {
+		if err := a.assumeRoleWithSAML(ctx, sessionCredential(a.Credential, a.Chain)); err != nil {
+			return "", err
+		}
+		start = 0
 	}
 
-	a.Credential.SetAccessKeyID(stsRes.Credentials.AccessKeyId)
-	a.Credential.SetExpiration(stsRes.Credentials.Expiration)
-	a.Credential.SetSecretAccessKey(stsRes.Credentials.SecretAccessKey)
-	a.Credential.SetSessionToken(stsRes.Credentials.SessionToken)
-	if err := a.Credential.Save(); err != nil {
+	if err := assumeChain(ctx, a.Credential, a.STS, a.Chain, start); err != nil {
 		return "", err
 	}
 
@@ -60,3 +100,132 @@ func (a *Auth) SAMLAuth() (string, error) {
 
 	return out, nil
 }
+
+// assumeRoleWithSAML signs in and saves the credentials of the role into cred.
+// A cached assertion rejected by STS is dropped and a new one is signed in for.
+func (a *Auth) assumeRoleWithSAML(ctx context.Context, cred credential.Credentialer) error {
+	var selected *saml.Role
+	for {
+		samlRes, err := a.SAML.SigninContext(ctx)
+		if err != nil {
+			return err
+		}
+
+		role := saml.Role{RoleArn: samlRes.RoleArn, PrincipalArn: samlRes.PrincipalArn}
+		if role.PrincipalArn == "" {
+			if selected == nil {
+				if a.SelectRole == nil {
+					return errors.New("aws role arn must be set")
+				}
+
+				selected, err = a.SelectRole(samlRes.Roles)
+				if err != nil {
+					return fmt.Errorf("could not select role: %w", err)
+				}
+
+				a.STS.SetAwsRoleArn(selected.RoleArn)
+			}
+			role = *selected
+		}
+
+		err = assumeRole(ctx, cred, a.STS, role, samlRes)
+		if err == nil || !samlRes.Cached {
+			return err
+		}
+
+		if err := a.SAML.ForgetAssertion(); err != nil {
+			return err
+		}
+	}
+}
+
+// sessionCredential returns where the credentials of the SAML session are saved.
+func sessionCredential(cred credential.Credentialer, chain []Hop) credential.Credentialer {
+	if len(chain) > 0 {
+		return chain[0].Source
+	}
+
+	return cred
+}
+
+// resumableHop returns the index of the last hop whose source credentials
+// are cached and valid, or -1 if the chain must start from a new signin.
+func resumableHop(chain []Hop) (int, error) {
+	for i := len(chain) - 1; i >= 0; i-- {
+		if err := chain[i].Source.Load(); err != nil {
+			return -1, err
+		}
+		if !chain[i].Source.IsExpired() {
+			return i, nil
+		}
+	}
+
+	return -1, nil
+}
+
+// assumeRole assumes the role of sts with the SAML assertion
+// and saves the credentials.
+func assumeRole(ctx context.Context, cred credential.Credentialer, s sts.STSer, role saml.Role, samlRes *saml.Response) error {
+	s.SetAwsPrincipalArn(role.PrincipalArn)
+	s.SetSAMLAssertion(samlRes.SAMLResponse)
+	if samlRes.Assertion != nil {
+		s.SetSAMLSessionDuration(int32(samlRes.Assertion.SessionDuration.Seconds()))
+	}
+	stsRes, err := s.AssumeRoleWithSAMLContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	return saveCredentials(cred, role.RoleArn, stsRes.Credentials)
+}
+
+// assumeChain assumes the hops of the chain from start, saving the
+// credentials of each hop into the source of the next one and those of the
+// last hop into cred. The expiration is the earliest one across the chain.
+func assumeChain(ctx context.Context, cred credential.Credentialer, s sts.STSer, chain []Hop, start int) error {
+	for i := start; i < len(chain); i++ {
+		src, err := chain[i].Source.Value()
+		if err != nil {
+			return err
+		}
+
+		c, err := s.AssumeRoleContext(ctx, chain[i].Role, &types.Credentials{
+			AccessKeyId:     &src.AccessKeyID,
+			SecretAccessKey: &src.SecretAccessKey,
+			SessionToken:    &src.SessionToken,
+			Expiration:      &src.Expiration,
+		})
+		if err != nil {
+			return err
+		}
+		if c.Expiration == nil || src.Expiration.Before(*c.Expiration) {
+			c.Expiration = &src.Expiration
+		}
+
+		dst := cred
+		if i+1 < len(chain) {
+			dst = chain[i+1].Source
+		}
+		if err := saveCredentials(dst, chain[i].Role.RoleArn, c); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func saveCredentials(cred credential.Credentialer, roleArn string, c *types.Credentials) error {
+	cred.SetAccessKeyID(c.AccessKeyId)
+	cred.SetRoleArn(roleArn)
+	cred.SetExpiration(c.Expiration)
+	cred.SetSecretAccessKey(c.SecretAccessKey)
+	cred.SetSessionToken(c.SessionToken)
+	if err := cred.Save(); err != nil {
+		return err
+	}
+	if cred.IsExpired() {
+		return fmt.Errorf("new credentials expire at %s, before the required remaining validity", c.Expiration.Format(time.RFC3339))
+	}
+
+	return nil
+}
@@ -1,7 +1,12 @@
 package auth_test
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
 	"testing"
 	"time"
 
@@ -9,7 +14,9 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/sts/types"
 	"github.com/google/go-cmp/cmp"
 	"github.com/walkersumida/aws-sso-google/auth"
+	"github.com/walkersumida/aws-sso-google/credential"
 	cmock "github.com/walkersumida/aws-sso-google/credential/mock"
+	lmock "github.com/walkersumida/aws-sso-google/lock/mock"
 	"github.com/walkersumida/aws-sso-google/saml"
 	smock "github.com/walkersumida/aws-sso-google/saml/mock"
 	"github.com/walkersumida/aws-sso-google/sts"
@@ -42,7 +49,7 @@ func TestSAMLAuth(t *testing.T) {
 
 			cred := newCredentialMock()
 			cred.IsExpiredFunc = func() bool {
-				return tt.giveIsExpired
+				return tt.giveIsExpired && len(cred.SetExpirationCalls()) == 0
 			}
 			cred.OutputFunc = func() (string, error) {
 				return toOutput("access-key", "2024-01-01T00:00:00Z", "secret", "session"), nil
@@ -61,13 +68,374 @@ func TestSAMLAuth(t *testing.T) {
 				t.Errorf("mismatch (-want +got): ¥n%s", diff)
 			}
 
-			if diff := cmp.Diff(tt.wantSigninCalls, len(saml.SigninCalls())); diff != "" {
+			if diff := cmp.Diff(tt.wantSigninCalls, len(saml.SigninContextCalls())); diff != "" {
 				t.Errorf("mismatch (-want +got): ¥n%s", diff)
 			}
 		})
 	}
 }
 
+func TestSAMLAuthInsufficientValidity(t *testing.T) {
+	t.Parallel()
+
+	cred := newCredentialMock()
+	cred.IsExpiredFunc = func() bool {
+		return true
+	}
+
+	a := auth.New(cred, newSAMLMock(), newSTSMock())
+
+	if _, err := a.SAMLAuth(); err == nil {
+		t.Error("want error, got nil")
+	}
+}
+
+func TestSAMLAuthContextCanceled(t *testing.T) {
+	t.Parallel()
+
+	cred := newCredentialMock()
+	cred.IsExpiredFunc = func() bool {
+		return true
+	}
+	samlMock := newSAMLMock()
+	samlMock.SigninContextFunc = func(ctx context.Context) (*saml.Response, error) {
+		return nil, ctx.Err()
+	}
+	stsMock := newSTSMock()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	a := auth.New(cred, samlMock, stsMock)
+	if _, err := a.SAMLAuthContext(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("want context.Canceled, got %v", err)
+	}
+
+	if diff := cmp.Diff(0, len(stsMock.AssumeRoleWithSAMLContextCalls())); diff != "" {
+		t.Errorf("mismatch (-want +got): ¥n%s", diff)
+	}
+}
+
+func TestSAMLAuthCachedAssertion(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		giveRejected    bool
+		wantSigninCalls int
+		wantForgetCalls int
+		wantAssumeCalls int
+	}{
+		"when STS accepts the cached assertion": {
+			giveRejected:    false,
+			wantSigninCalls: 1,
+			wantForgetCalls: 0,
+			wantAssumeCalls: 1,
+		},
+		"when STS rejects the cached assertion": {
+			giveRejected:    true,
+			wantSigninCalls: 2,
+			wantForgetCalls: 1,
+			wantAssumeCalls: 2,
+		},
+	}
+	for name, tt := range tests {
+		tt, name := tt, name
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			cred := newCredentialMock()
+			cred.IsExpiredFunc = func() bool {
+				return len(cred.SetExpirationCalls()) == 0
+			}
+			samlMock := newSAMLMock()
+			samlMock.SigninContextFunc = func(ctx context.Context) (*saml.Response, error) {
+				return &saml.Response{
+					PrincipalArn: "arn:aws:iam::123456789012:saml-provider/provider",
+					SAMLResponse: "saml",
+					Cached:       len(samlMock.ForgetAssertionCalls()) == 0,
+				}, nil
+			}
+			stsMock := newSTSMock()
+			assumeRoleWithSAML := stsMock.AssumeRoleWithSAMLContextFunc
+			stsMock.AssumeRoleWithSAMLContextFunc = func(ctx context.Context) (*sts.Response, error) {
+				if tt.giveRejected && len(stsMock.AssumeRoleWithSAMLContextCalls()) == 1 {
+					return nil, errors.New("rejected")
+				}
+				return assumeRoleWithSAML(ctx)
+			}
+
+			a := auth.New(cred, samlMock, stsMock)
+			if _, err := a.SAMLAuth(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.wantSigninCalls, len(samlMock.SigninContextCalls())); diff != "" {
+				t.Errorf("mismatch (-want +got): ¥n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantForgetCalls, len(samlMock.ForgetAssertionCalls())); diff != "" {
+				t.Errorf("mismatch (-want +got): ¥n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantAssumeCalls, len(stsMock.AssumeRoleWithSAMLContextCalls())); diff != "" {
+				t.Errorf("mismatch (-want +got): ¥n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSAMLAuthLock(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		giveRefreshedByOther bool
+		wantSigninCalls      int
+	}{
+		"when another process refreshed the credential while waiting": {
+			giveRefreshedByOther: true,
+			wantSigninCalls:      0,
+		},
+		"when no other process refreshed the credential": {
+			giveRefreshedByOther: false,
+			wantSigninCalls:      1,
+		},
+	}
+	for name, tt := range tests {
+		tt, name := tt, name
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			locked := false
+			cred := newCredentialMock()
+			cred.IsExpiredFunc = func() bool {
+				return !(locked && tt.giveRefreshedByOther) && len(cred.SetExpirationCalls()) == 0
+			}
+			samlMock := newSAMLMock()
+			locker := &lmock.LockerMock{
+				LockContextFunc: func(ctx context.Context) error {
+					locked = true
+					return nil
+				},
+				UnlockFunc: func() error {
+					return nil
+				},
+			}
+
+			a := auth.New(cred, samlMock, newSTSMock())
+			a.Locker = locker
+
+			if _, err := a.SAMLAuth(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.wantSigninCalls, len(samlMock.SigninContextCalls())); diff != "" {
+				t.Errorf("mismatch (-want +got): ¥n%s", diff)
+			}
+			if diff := cmp.Diff(2, len(cred.LoadCalls())); diff != "" {
+				t.Errorf("mismatch (-want +got): ¥n%s", diff)
+			}
+			if diff := cmp.Diff(1, len(locker.UnlockCalls())); diff != "" {
+				t.Errorf("mismatch (-want +got): ¥n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSAMLAuthSelectRole(t *testing.T) {
+	t.Parallel()
+
+	roles := []saml.Role{
+		{
+			RoleArn:      "arn:aws:iam::123456789012:role/role-a",
+			PrincipalArn: "arn:aws:iam::123456789012:saml-provider/provider",
+		},
+		{
+			RoleArn:      "arn:aws:iam::123456789012:role/role-b",
+			PrincipalArn: "arn:aws:iam::123456789012:saml-provider/provider",
+		},
+	}
+
+	cred := newCredentialMock()
+	cred.IsExpiredFunc = func() bool {
+		return len(cred.SetExpirationCalls()) == 0
+	}
+	samlMock := newSAMLMock()
+	samlMock.SigninContextFunc = func(ctx context.Context) (*saml.Response, error) {
+		return &saml.Response{
+			Roles:        roles,
+			SAMLResponse: "saml",
+		}, nil
+	}
+	stsMock := newSTSMock()
+
+	a := auth.New(cred, samlMock, stsMock)
+	a.SelectRole = func(rs []saml.Role) (*saml.Role, error) {
+		return &rs[1], nil
+	}
+
+	if _, err := a.SAMLAuth(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if diff := cmp.Diff("arn:aws:iam::123456789012:role/role-b", stsMock.SetAwsRoleArnCalls()[0].S); diff != "" {
+		t.Errorf("mismatch (-want +got): ¥n%s", diff)
+	}
+	if diff := cmp.Diff("arn:aws:iam::123456789012:saml-provider/provider", stsMock.SetAwsPrincipalArnCalls()[0].S); diff != "" {
+		t.Errorf("mismatch (-want +got): ¥n%s", diff)
+	}
+	if diff := cmp.Diff("arn:aws:iam::123456789012:role/role-b", cred.SetRoleArnCalls()[0].S); diff != "" {
+		t.Errorf("mismatch (-want +got): ¥n%s", diff)
+	}
+}
+
+func TestSAMLAuthChain(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := map[string]struct {
+		// giveSources is the remaining validity of the cached source
+		// credentials of each hop, or zero if they are not cached.
+		giveSources     []time.Duration
+		giveMinValidity time.Duration
+		wantSigninCalls int
+		wantAssumeCalls []string
+	}{
+		"when no source is valid": {
+			giveSources:     []time.Duration{0, 0},
+			wantSigninCalls: 1,
+			wantAssumeCalls: []string{
+				"arn:aws:iam::222222222222:role/hop-1",
+				"arn:aws:iam::333333333333:role/hop-2",
+			},
+		},
+		"when the SAML session is valid": {
+			giveSources:     []time.Duration{time.Hour, 0},
+			wantSigninCalls: 0,
+			wantAssumeCalls: []string{
+				"arn:aws:iam::222222222222:role/hop-1",
+				"arn:aws:iam::333333333333:role/hop-2",
+			},
+		},
+		"when the first hop is valid": {
+			giveSources:     []time.Duration{time.Hour, time.Hour},
+			wantSigninCalls: 0,
+			wantAssumeCalls: []string{
+				"arn:aws:iam::333333333333:role/hop-2",
+			},
+		},
+		"when the SAML session is valid but shorter than the min validity": {
+			giveSources:     []time.Duration{30 * time.Minute, 0},
+			giveMinValidity: 50 * time.Minute,
+			wantSigninCalls: 1,
+			wantAssumeCalls: []string{
+				"arn:aws:iam::222222222222:role/hop-1",
+				"arn:aws:iam::333333333333:role/hop-2",
+			},
+		},
+	}
+	for name, tt := range tests {
+		tt, name := tt, name
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			cred := newCredentialMock()
+			cred.IsExpiredFunc = func() bool {
+				return len(cred.SetExpirationCalls()) == 0
+			}
+			storage := memoryStorage{}
+			var chain []auth.Hop
+			for i, roleArn := range []string{
+				"arn:aws:iam::222222222222:role/hop-1",
+				"arn:aws:iam::333333333333:role/hop-2",
+			} {
+				name := fmt.Sprintf("example#%d", i)
+				if tt.giveSources[i] > 0 {
+					storage[name] = &credential.Value{
+						AccessKeyID:     "cached-access-key-id",
+						SecretAccessKey: "cached-secret-access-key",
+						SessionToken:    "cached-session-token",
+						Expiration:      now.Add(tt.giveSources[i]),
+					}
+				}
+				src := credential.New(name, storage)
+				src.RefreshSkew = 5 * time.Minute
+				src.MinValidity = tt.giveMinValidity
+				src.Now = func() time.Time { return now }
+				chain = append(chain, auth.Hop{
+					Role:   sts.Hop{RoleArn: roleArn},
+					Source: src,
+				})
+			}
+			samlMock := newSAMLMock()
+			stsMock := newSTSMock()
+			expiration := now.Add(time.Hour)
+			stsMock.AssumeRoleWithSAMLContextFunc = func(ctx context.Context) (*sts.Response, error) {
+				return &sts.Response{
+					AssumeRoleWithSAMLOutput: sdksts.AssumeRoleWithSAMLOutput{
+						Credentials: &types.Credentials{
+							AccessKeyId:     toPointer("access-key-id"),
+							Expiration:      &expiration,
+							SecretAccessKey: toPointer("secret-access-key"),
+							SessionToken:    toPointer("session-token"),
+						},
+					},
+				}, nil
+			}
+			stsMock.AssumeRoleContextFunc = func(ctx context.Context, hop sts.Hop, source *types.Credentials) (*types.Credentials, error) {
+				return &types.Credentials{
+					AccessKeyId:     toPointer("chained-access-key-id"),
+					Expiration:      &expiration,
+					SecretAccessKey: toPointer("chained-secret-access-key"),
+					SessionToken:    toPointer("chained-session-token"),
+				}, nil
+			}
+
+			a := auth.New(cred, samlMock, stsMock)
+			a.Chain = chain
+
+			if _, err := a.SAMLAuth(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.wantSigninCalls, len(samlMock.SigninContextCalls())); diff != "" {
+				t.Errorf("mismatch (-want +got): ¥n%s", diff)
+			}
+			var gotAssumeCalls []string
+			for _, c := range stsMock.AssumeRoleContextCalls() {
+				gotAssumeCalls = append(gotAssumeCalls, c.Hop.RoleArn)
+			}
+			if diff := cmp.Diff(tt.wantAssumeCalls, gotAssumeCalls); diff != "" {
+				t.Errorf("mismatch (-want +got): ¥n%s", diff)
+			}
+			if diff := cmp.Diff(1, len(cred.SaveCalls())); diff != "" {
+				t.Errorf("mismatch (-want +got): ¥n%s", diff)
+			}
+			if diff := cmp.Diff("arn:aws:iam::333333333333:role/hop-2", cred.SetRoleArnCalls()[0].S); diff != "" {
+				t.Errorf("mismatch (-want +got): ¥n%s", diff)
+			}
+		})
+	}
+}
+
+// memoryStorage is a credential.Storage keeping the credentials in memory.
+type memoryStorage map[string]*credential.Value
+
+func (s memoryStorage) Load(profile string) (*credential.Value, error) {
+	return s[profile], nil
+}
+
+func (s memoryStorage) Save(profile string, v *credential.Value) error {
+	s[profile] = v
+	return nil
+}
+
+func (s memoryStorage) Delete(profile string) error {
+	delete(s, profile)
+	return nil
+}
+
+func (s memoryStorage) Profiles() ([]string, error) {
+	return slices.Sorted(maps.Keys(s)), nil
+}
+
 func newCredentialMock() *cmock.CredentialerMock {
 	return &cmock.CredentialerMock{
 		LoadFunc: func() error {
@@ -81,17 +449,29 @@ func newCredentialMock() *cmock.CredentialerMock {
 		},
 		SetAccessKeyIDFunc:     func(s *string) {},
 		SetExpirationFunc:      func(t *time.Time) {},
+		SetRoleArnFunc:         func(s string) {},
 		SetSecretAccessKeyFunc: func(s *string) {},
 		SetSessionTokenFunc:    func(s *string) {},
 		SaveFunc: func() error {
 			return nil
 		},
+		ValueFunc: func() (*credential.Value, error) {
+			return &credential.Value{
+				AccessKeyID:     "access-key-id",
+				SecretAccessKey: "secret-access-key",
+				SessionToken:    "session-token",
+				Expiration:      time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			}, nil
+		},
 	}
 }
 
 func newSAMLMock() *smock.SAMLerMock {
 	return &smock.SAMLerMock{
-		SigninFunc: func() (*saml.Response, error) {
+		ForgetAssertionFunc: func() error {
+			return nil
+		},
+		SigninContextFunc: func(ctx context.Context) (*saml.Response, error) {
 			return &saml.Response{
 				PrincipalArn: "arn:aws:iam::123456789012:role/role-name",
 				SAMLResponse: "saml",
@@ -102,9 +482,11 @@ func newSAMLMock() *smock.SAMLerMock {
 
 func newSTSMock() *stsmock.STSerMock {
 	return &stsmock.STSerMock{
-		SetAwsPrincipalArnFunc: func(s string) {},
-		SetSAMLAssertionFunc:   func(s string) {},
-		AssumeRoleWithSAMLFunc: func() (*sts.Response, error) {
+		SetAwsPrincipalArnFunc:     func(s string) {},
+		SetAwsRoleArnFunc:          func(s string) {},
+		SetSAMLAssertionFunc:       func(s string) {},
+		SetSAMLSessionDurationFunc: func(n int32) {},
+		AssumeRoleWithSAMLContextFunc: func(ctx context.Context) (*sts.Response, error) {
 			return &sts.Response{
 				AssumeRoleWithSAMLOutput: sdksts.AssumeRoleWithSAMLOutput{
 					Credentials: &types.Credentials{
@@ -116,6 +498,14 @@ func newSTSMock() *stsmock.STSerMock {
 				},
 			}, nil
 		},
+		AssumeRoleContextFunc: func(ctx context.Context, hop sts.Hop, source *types.Credentials) (*types.Credentials, error) {
+			return &types.Credentials{
+				AccessKeyId:     toPointer("chained-access-key-id"),
+				Expiration:      toPointer(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)),
+				SecretAccessKey: toPointer("chained-secret-access-key"),
+				SessionToken:    toPointer("chained-session-token"),
+			}, nil
+		},
 	}
 }
 
@@ -0,0 +1,138 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/walkersumida/aws-sso-google/credential"
+	"github.com/walkersumida/aws-sso-google/lock"
+	"github.com/walkersumida/aws-sso-google/saml"
+	"github.com/walkersumida/aws-sso-google/sts"
+)
+
+// Target is a profile refreshed by Batch.
+type Target struct {
+	AwsProfile string
+	AwsRoleArn string
+	Credential credential.Credentialer
+	STS        sts.STSer
+
+	// Chain is the roles assumed from AwsRoleArn. See Auth.Chain.
+	Chain []Hop
+}
+
+// Batch refreshes the credentials of several profiles with one signin,
+// since a SAML assertion lists every role the user can assume.
+type Batch struct {
+	SAML    saml.SAMLer
+	Targets []Target
+
+	// Force refreshes credentials that are not expired yet.
+	Force bool
+
+	// Locker serializes the signin across processes. It is optional.
+	Locker lock.Locker
+}
+
+func NewBatch(saml saml.SAMLer, targets []Target) *Batch {
+	return &Batch{
+		SAML:    saml,
+		Targets: targets,
+	}
+}
+
+// Refresh signs in once and assumes the role of every expired target with
+// the same SAML assertion. The returned errors are indexed like Targets and
+// are nil for targets that were refreshed or did not need to be.
+func (b *Batch) Refresh() ([]error, error) {
+	return b.RefreshContext(context.Background())
+}
+
+// RefreshContext is Refresh that gives up waiting for the lock,
+// the signin and STS when ctx is done.
+func (b *Batch) RefreshContext(ctx context.Context) ([]error, error) {
+	if b.Locker != nil {
+		if err := b.Locker.LockContext(ctx); err != nil {
+			return nil, fmt.Errorf("could not acquire lock: %w", err)
+		}
+		defer func() { _ = b.Locker.Unlock() }()
+	}
+
+	errs := make([]error, len(b.Targets))
+	var expired []int
+	for i, t := range b.Targets {
+		if err := t.Credential.Load(); err != nil {
+			errs[i] = err
+			continue
+		}
+		if !b.Force && !t.Credential.IsExpired() {
+			continue
+		}
+
+		start := -1
+		if !b.Force {
+			start, errs[i] = resumableHop(t.Chain)
+			if errs[i] != nil {
+				continue
+			}
+		}
+		if start < 0 {
+			expired = append(expired, i)
+			continue
+		}
+		errs[i] = assumeChain(ctx, t.Credential, t.STS, t.Chain, start)
+	}
+	if len(expired) == 0 {
+		return errs, nil
+	}
+
+	samlRes, err := b.SAML.SigninContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rejected := b.assumeRoles(ctx, samlRes, expired, errs)
+	if len(rejected) > 0 && samlRes.Cached {
+		// STS may reject a cached assertion, so retry with a new one.
+		if err := b.SAML.ForgetAssertion(); err != nil {
+			return nil, err
+		}
+
+		samlRes, err = b.SAML.SigninContext(ctx)
+		if err != nil {
+			return nil, err
+		}
+		b.assumeRoles(ctx, samlRes, rejected, errs)
+	}
+
+	return errs, nil
+}
+
+// assumeRoles assumes the roles of the targets with the SAML assertion,
+// setting their errors in errs, and returns the targets STS failed for.
+func (b *Batch) assumeRoles(ctx context.Context, samlRes *saml.Response, targets []int, errs []error) []int {
+	principalArns := map[string]string{}
+	for _, r := range samlRes.Roles {
+		principalArns[r.RoleArn] = r.PrincipalArn
+	}
+
+	var rejected []int
+	for _, i := range targets {
+		t := b.Targets[i]
+		principalArn, ok := principalArns[t.AwsRoleArn]
+		if !ok {
+			errs[i] = fmt.Errorf("could not find arn: %s", t.AwsRoleArn)
+			continue
+		}
+
+		role := saml.Role{RoleArn: t.AwsRoleArn, PrincipalArn: principalArn}
+		if err := assumeRole(ctx, sessionCredential(t.Credential, t.Chain), t.STS, role, samlRes); err != nil {
+			errs[i] = err
+			rejected = append(rejected, i)
+			continue
+		}
+		errs[i] = assumeChain(ctx, t.Credential, t.STS, t.Chain, 0)
+	}
+
+	return rejected
+}
@@ -0,0 +1,112 @@
+package auth_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/walkersumida/aws-sso-google/auth"
+	"github.com/walkersumida/aws-sso-google/saml"
+	stsmock "github.com/walkersumida/aws-sso-google/sts/mock"
+)
+
+func TestBatchRefresh(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		giveExpired     []bool
+		giveForce       bool
+		wantSigninCalls int
+		wantAssumeCalls []int
+		wantErrs        []bool
+	}{
+		"when some credentials are expired": {
+			giveExpired:     []bool{false, true, true},
+			wantSigninCalls: 1,
+			wantAssumeCalls: []int{0, 1, 0},
+			wantErrs:        []bool{false, false, true},
+		},
+		"when no credential is expired": {
+			giveExpired:     []bool{false, false, false},
+			wantSigninCalls: 0,
+			wantAssumeCalls: []int{0, 0, 0},
+			wantErrs:        []bool{false, false, false},
+		},
+		"when forced": {
+			giveExpired:     []bool{false, false, false},
+			giveForce:       true,
+			wantSigninCalls: 1,
+			wantAssumeCalls: []int{1, 1, 0},
+			wantErrs:        []bool{false, false, true},
+		},
+	}
+	for name, tt := range tests {
+		tt, name := tt, name
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			samlMock := newSAMLMock()
+			samlMock.SigninContextFunc = func(ctx context.Context) (*saml.Response, error) {
+				return &saml.Response{
+					Roles: []saml.Role{
+						{
+							RoleArn:      "arn:aws:iam::111111111111:role/role-a",
+							PrincipalArn: "arn:aws:iam::111111111111:saml-provider/provider",
+						},
+						{
+							RoleArn:      "arn:aws:iam::222222222222:role/role-b",
+							PrincipalArn: "arn:aws:iam::222222222222:saml-provider/provider",
+						},
+					},
+					SAMLResponse: "saml",
+				}, nil
+			}
+
+			roleArns := []string{
+				"arn:aws:iam::111111111111:role/role-a",
+				"arn:aws:iam::222222222222:role/role-b",
+				"arn:aws:iam::333333333333:role/role-c",
+			}
+			var targets []auth.Target
+			var stsMocks []*stsmock.STSerMock
+			for i, roleArn := range roleArns {
+				expired := tt.giveExpired[i]
+				cred := newCredentialMock()
+				cred.IsExpiredFunc = func() bool {
+					return expired && len(cred.SetExpirationCalls()) == 0
+				}
+				stsMock := newSTSMock()
+				stsMocks = append(stsMocks, stsMock)
+				targets = append(targets, auth.Target{
+					AwsRoleArn: roleArn,
+					Credential: cred,
+					STS:        stsMock,
+				})
+			}
+
+			b := auth.NewBatch(samlMock, targets)
+			b.Force = tt.giveForce
+			errs, err := b.Refresh()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.wantSigninCalls, len(samlMock.SigninContextCalls())); diff != "" {
+				t.Errorf("mismatch (-want +got): ¥n%s", diff)
+			}
+
+			var gotAssumeCalls []int
+			var gotErrs []bool
+			for i := range targets {
+				gotAssumeCalls = append(gotAssumeCalls, len(stsMocks[i].AssumeRoleWithSAMLContextCalls()))
+				gotErrs = append(gotErrs, errs[i] != nil)
+			}
+			if diff := cmp.Diff(tt.wantAssumeCalls, gotAssumeCalls); diff != "" {
+				t.Errorf("mismatch (-want +got): ¥n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantErrs, gotErrs); diff != "" {
+				t.Errorf("mismatch (-want +got): ¥n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,319 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the content of the config file.
+//
+//	defaults:
+//	  idp_id: XXXXXXXXX
+//	  sp_id: "888888888888"
+//	  username: user@example.com
+//	profiles:
+//	  example:
+//	    aws_region: ap-northeast-1
+//	    aws_role_arn: arn:aws:iam::999999999999:role/RoleName
+//	    role_chain:
+//	      - role_arn: arn:aws:iam::888888888888:role/Target
+//	account_aliases:
+//	  "999999999999": example
+//	groups:
+//	  all-prod: [example]
+type Config struct {
+	Defaults       Profile             `yaml:"defaults,omitempty"`
+	Profiles       map[string]Profile  `yaml:"profiles,omitempty"`
+	AccountAliases map[string]string   `yaml:"account_aliases,omitempty"`
+	Groups         map[string][]string `yaml:"groups,omitempty"`
+}
+
+// Profile holds the settings of a profile.
+// Zero values mean "not set" so that profiles can be merged.
+// Booleans are pointers so that false can override true.
+type Profile struct {
+	AssertionCache     string        `yaml:"assertion_cache,omitempty"`
+	AwsRegion          string        `yaml:"aws_region,omitempty"`
+	AwsRoleArn         string        `yaml:"aws_role_arn,omitempty"`
+	AwsSessionDuration int32         `yaml:"aws_session_duration,omitempty"`
+	Browser            string        `yaml:"browser,omitempty"`
+	BrowserCDPURL      string        `yaml:"browser_cdp_url,omitempty"`
+	BrowserPath        string        `yaml:"browser_path,omitempty"`
+	Clean              *bool         `yaml:"clean,omitempty"`
+	CredentialStorage  string        `yaml:"credential_storage,omitempty"`
+	FederationURL      string        `yaml:"federation_url,omitempty"`
+	Headless           *bool         `yaml:"headless,omitempty"`
+	HeadlessTimeout    time.Duration `yaml:"headless_timeout,omitempty"`
+	IDPCertificate     string        `yaml:"idp_certificate,omitempty"`
+	IDPEntityID        string        `yaml:"idp_entity_id,omitempty"`
+	IDPID              string        `yaml:"idp_id,omitempty"`
+	IDPMetadata        string        `yaml:"idp_metadata,omitempty"`
+	LockTimeout        time.Duration `yaml:"lock_timeout,omitempty"`
+	LoginTimeout       time.Duration `yaml:"login_timeout,omitempty"`
+	MinValidity        time.Duration `yaml:"min_validity,omitempty"`
+	Partition          string        `yaml:"partition,omitempty"`
+	RefreshSkew        time.Duration `yaml:"refresh_skew,omitempty"`
+	RoleChain          []Hop         `yaml:"role_chain,omitempty"`
+	SpID               string        `yaml:"sp_id,omitempty"`
+	Username           string        `yaml:"username,omitempty"`
+}
+
+// Hop is a role assumed from the credentials of the previous role in a chain.
+type Hop struct {
+	RoleArn     string `yaml:"role_arn"`
+	ExternalID  string `yaml:"external_id,omitempty"`
+	SessionName string `yaml:"session_name,omitempty"`
+}
+
+// Load reads the config file.
+// An empty config is returned if the file does not exist.
+func Load(p string) (*Config, error) {
+	b, err := os.ReadFile(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return &Config{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read config file: %w", err)
+	}
+
+	cfg := &Config{}
+	if err := yaml.Unmarshal(b, cfg); err != nil {
+		return nil, fmt.Errorf("could not parse config file %s: %w", p, err)
+	}
+
+	return cfg, nil
+}
+
+// Save writes the config file.
+// The comments, key order and formatting of the existing file are kept
+// for the values that do not change.
+func (c *Config) Save(p string) error {
+	b, err := c.marshal(p)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(p), 0700); err != nil {
+		return fmt.Errorf("could not create config dir: %w", err)
+	}
+
+	if err := os.WriteFile(p, b, 0600); err != nil {
+		return fmt.Errorf("could not write config file: %w", err)
+	}
+
+	return nil
+}
+
+// marshal returns c as YAML, merged into the existing file at p if any.
+func (c *Config) marshal(p string) ([]byte, error) {
+	src := &yaml.Node{}
+	if err := src.Encode(c); err != nil {
+		return nil, fmt.Errorf("could not marshal config: %w", err)
+	}
+
+	doc := &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{src}}
+	b, err := os.ReadFile(p)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("could not read config file: %w", err)
+	}
+	if len(b) > 0 {
+		existing := &yaml.Node{}
+		if err := yaml.Unmarshal(b, existing); err != nil {
+			return nil, fmt.Errorf("could not parse config file %s: %w", p, err)
+		}
+		if len(existing.Content) == 1 {
+			mergeNode(existing.Content[0], src)
+			doc = existing
+		}
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return nil, fmt.Errorf("could not marshal config: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, fmt.Errorf("could not marshal config: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// mergeNode updates dst to the value of src.
+// The nodes of dst whose value does not change are kept as they are,
+// and the others keep their comments.
+func mergeNode(dst, src *yaml.Node) {
+	switch {
+	case dst.Kind == yaml.MappingNode && src.Kind == yaml.MappingNode:
+		srcValues := map[string]*yaml.Node{}
+		var srcKeys []*yaml.Node
+		for i := 0; i+1 < len(src.Content); i += 2 {
+			srcValues[src.Content[i].Value] = src.Content[i+1]
+			srcKeys = append(srcKeys, src.Content[i])
+		}
+
+		content := make([]*yaml.Node, 0, len(src.Content))
+		for i := 0; i+1 < len(dst.Content); i += 2 {
+			key, value := dst.Content[i], dst.Content[i+1]
+			v, ok := srcValues[key.Value]
+			if !ok {
+				continue
+			}
+			mergeNode(value, v)
+			content = append(content, key, value)
+			delete(srcValues, key.Value)
+		}
+		for _, key := range srcKeys {
+			if v, ok := srcValues[key.Value]; ok {
+				content = append(content, key, v)
+			}
+		}
+		dst.Content = content
+	case dst.Kind == yaml.SequenceNode && src.Kind == yaml.SequenceNode:
+		for i := 0; i < len(dst.Content) && i < len(src.Content); i++ {
+			mergeNode(dst.Content[i], src.Content[i])
+		}
+		if len(dst.Content) > len(src.Content) {
+			dst.Content = dst.Content[:len(src.Content)]
+		} else {
+			dst.Content = append(dst.Content, src.Content[len(dst.Content):]...)
+		}
+	case dst.Kind == yaml.ScalarNode && src.Kind == yaml.ScalarNode && dst.Value == src.Value:
+	default:
+		head, line, foot := dst.HeadComment, dst.LineComment, dst.FootComment
+		*dst = *src
+		dst.HeadComment, dst.LineComment, dst.FootComment = head, line, foot
+	}
+}
+
+// Profile returns the named profile merged over the defaults.
+func (c *Config) Profile(name string) Profile {
+	return c.Defaults.Merge(c.Profiles[name])
+}
+
+// ProfileNames returns the names of the profiles in the config file in order.
+func (c *Config) ProfileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// SetAwsRoleArn sets the role arn of the named profile.
+func (c *Config) SetAwsRoleArn(name, roleArn string) {
+	if c.Profiles == nil {
+		c.Profiles = map[string]Profile{}
+	}
+
+	p := c.Profiles[name]
+	p.AwsRoleArn = roleArn
+	c.Profiles[name] = p
+}
+
+// MergeProfile overrides the named profile, or the defaults if name is
+// empty, with the values set in o.
+func (c *Config) MergeProfile(name string, o Profile) {
+	if name == "" {
+		c.Defaults = c.Defaults.Merge(o)
+		return
+	}
+
+	if c.Profiles == nil {
+		c.Profiles = map[string]Profile{}
+	}
+	c.Profiles[name] = c.Profiles[name].Merge(o)
+}
+
+// Bool returns the value of b, or false if b is not set.
+func Bool(b *bool) bool {
+	return b != nil && *b
+}
+
+// Merge returns a copy of p overridden by the values set in o.
+func (p Profile) Merge(o Profile) Profile {
+	if o.AssertionCache != "" {
+		p.AssertionCache = o.AssertionCache
+	}
+	if o.AwsRegion != "" {
+		p.AwsRegion = o.AwsRegion
+	}
+	if o.AwsRoleArn != "" {
+		p.AwsRoleArn = o.AwsRoleArn
+	}
+	if o.AwsSessionDuration != 0 {
+		p.AwsSessionDuration = o.AwsSessionDuration
+	}
+	if o.Browser != "" {
+		p.Browser = o.Browser
+	}
+	if o.BrowserCDPURL != "" {
+		p.BrowserCDPURL = o.BrowserCDPURL
+	}
+	if o.BrowserPath != "" {
+		p.BrowserPath = o.BrowserPath
+	}
+	if o.Clean != nil {
+		p.Clean = o.Clean
+	}
+	if o.CredentialStorage != "" {
+		p.CredentialStorage = o.CredentialStorage
+	}
+	if o.FederationURL != "" {
+		p.FederationURL = o.FederationURL
+	}
+	if o.Headless != nil {
+		p.Headless = o.Headless
+	}
+	if o.HeadlessTimeout != 0 {
+		p.HeadlessTimeout = o.HeadlessTimeout
+	}
+	if o.IDPCertificate != "" {
+		p.IDPCertificate = o.IDPCertificate
+	}
+	if o.IDPEntityID != "" {
+		p.IDPEntityID = o.IDPEntityID
+	}
+	if o.IDPID != "" {
+		p.IDPID = o.IDPID
+	}
+	if o.IDPMetadata != "" {
+		p.IDPMetadata = o.IDPMetadata
+	}
+	if o.LockTimeout != 0 {
+		p.LockTimeout = o.LockTimeout
+	}
+	if o.LoginTimeout != 0 {
+		p.LoginTimeout = o.LoginTimeout
+	}
+	if o.MinValidity != 0 {
+		p.MinValidity = o.MinValidity
+	}
+	if o.Partition != "" {
+		p.Partition = o.Partition
+	}
+	if o.RefreshSkew != 0 {
+		p.RefreshSkew = o.RefreshSkew
+	}
+	if len(o.RoleChain) > 0 {
+		p.RoleChain = o.RoleChain
+	}
+	if o.SpID != "" {
+		p.SpID = o.SpID
+	}
+	if o.Username != "" {
+		p.Username = o.Username
+	}
+
+	return p
+}
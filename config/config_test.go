@@ -0,0 +1,216 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/walkersumida/aws-sso-google/config"
+)
+
+const testConfig = `
+defaults:
+  idp_id: idp
+  sp_id: "888888888888"
+  username: user@example.com
+  aws_region: ap-northeast-1
+profiles:
+  prod:
+    aws_role_arn: arn:aws:iam::999999999999:role/Prod
+    aws_session_duration: 7200
+  dev:
+    aws_region: us-east-1
+    aws_role_arn: arn:aws:iam::111111111111:role/Dev
+  chained:
+    aws_role_arn: arn:aws:iam::999999999999:role/Prod
+    role_chain:
+      - role_arn: arn:aws:iam::222222222222:role/Target
+        external_id: external
+`
+
+func TestProfile(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		giveName string
+		want     config.Profile
+	}{
+		"when profile overrides nothing but role": {
+			giveName: "prod",
+			want: config.Profile{
+				AwsRegion:          "ap-northeast-1",
+				AwsRoleArn:         "arn:aws:iam::999999999999:role/Prod",
+				AwsSessionDuration: 7200,
+				IDPID:              "idp",
+				SpID:               "888888888888",
+				Username:           "user@example.com",
+			},
+		},
+		"when profile overrides defaults": {
+			giveName: "dev",
+			want: config.Profile{
+				AwsRegion:  "us-east-1",
+				AwsRoleArn: "arn:aws:iam::111111111111:role/Dev",
+				IDPID:      "idp",
+				SpID:       "888888888888",
+				Username:   "user@example.com",
+			},
+		},
+		"when profile has a role chain": {
+			giveName: "chained",
+			want: config.Profile{
+				AwsRegion:  "ap-northeast-1",
+				AwsRoleArn: "arn:aws:iam::999999999999:role/Prod",
+				IDPID:      "idp",
+				RoleChain: []config.Hop{
+					{RoleArn: "arn:aws:iam::222222222222:role/Target", ExternalID: "external"},
+				},
+				SpID:     "888888888888",
+				Username: "user@example.com",
+			},
+		},
+		"when profile does not exist": {
+			giveName: "unknown",
+			want: config.Profile{
+				AwsRegion: "ap-northeast-1",
+				IDPID:     "idp",
+				SpID:      "888888888888",
+				Username:  "user@example.com",
+			},
+		},
+	}
+	for name, tt := range tests {
+		tt, name := tt, name
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			p := filepath.Join(t.TempDir(), "config.yaml")
+			if err := os.WriteFile(p, []byte(testConfig), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			cfg, err := config.Load(p)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.want, cfg.Profile(tt.giveName)); diff != "" {
+				t.Errorf("mismatch (-want +got): ¥n%s", diff)
+			}
+		})
+	}
+}
+
+func TestMerge(t *testing.T) {
+	t.Parallel()
+
+	yes, no := true, false
+	base := config.Profile{
+		AwsRegion:  "ap-northeast-1",
+		AwsRoleArn: "arn:aws:iam::999999999999:role/Prod",
+		Headless:   &yes,
+		IDPID:      "idp",
+		SpID:       "sp",
+	}
+	flags := config.Profile{
+		AwsRoleArn: "arn:aws:iam::999999999999:role/Admin",
+		Clean:      &yes,
+		Headless:   &no,
+	}
+	want := config.Profile{
+		AwsRegion:  "ap-northeast-1",
+		AwsRoleArn: "arn:aws:iam::999999999999:role/Admin",
+		Clean:      &yes,
+		Headless:   &no,
+		IDPID:      "idp",
+		SpID:       "sp",
+	}
+
+	if diff := cmp.Diff(want, base.Merge(flags)); diff != "" {
+		t.Errorf("mismatch (-want +got): ¥n%s", diff)
+	}
+}
+
+func TestMergeProfile(t *testing.T) {
+	t.Parallel()
+
+	cfg := &config.Config{
+		Defaults: config.Profile{IDPID: "old", SpID: "sp"},
+	}
+	cfg.MergeProfile("", config.Profile{IDPID: "idp"})
+	cfg.MergeProfile("example", config.Profile{AwsRegion: "ap-northeast-1"})
+
+	want := &config.Config{
+		Defaults: config.Profile{IDPID: "idp", SpID: "sp"},
+		Profiles: map[string]config.Profile{
+			"example": {AwsRegion: "ap-northeast-1"},
+		},
+	}
+	if diff := cmp.Diff(want, cfg); diff != "" {
+		t.Errorf("mismatch (-want +got): ¥n%s", diff)
+	}
+}
+
+func TestLoadNotExist(t *testing.T) {
+	t.Parallel()
+
+	cfg, err := config.Load(filepath.Join(t.TempDir(), "config.yaml"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if diff := cmp.Diff(&config.Config{}, cfg); diff != "" {
+		t.Errorf("mismatch (-want +got): ¥n%s", diff)
+	}
+}
+
+func TestSave(t *testing.T) {
+	t.Parallel()
+
+	const give = `# aws-sso-google config
+defaults:
+  idp_id: idp # from the SAML app
+  sp_id: "888888888888"
+profiles:
+  # production
+  prod:
+    aws_role_arn: arn:aws:iam::999999999999:role/Prod
+    aws_region: ap-northeast-1 # Tokyo
+`
+	const want = `# aws-sso-google config
+defaults:
+  idp_id: idp # from the SAML app
+  sp_id: "888888888888"
+profiles:
+  # production
+  prod:
+    aws_role_arn: arn:aws:iam::999999999999:role/Admin
+    aws_region: ap-northeast-1 # Tokyo
+  dev:
+    aws_role_arn: arn:aws:iam::111111111111:role/Dev
+`
+
+	p := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(p, []byte(give), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := config.Load(p)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cfg.SetAwsRoleArn("prod", "arn:aws:iam::999999999999:role/Admin")
+	cfg.SetAwsRoleArn("dev", "arn:aws:iam::111111111111:role/Dev")
+	if err := cfg.Save(p); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	b, err := os.ReadFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want, string(b)); diff != "" {
+		t.Errorf("mismatch (-want +got): ¥n%s", diff)
+	}
+}
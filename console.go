@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/walkersumida/aws-sso-google/console"
+)
+
+func newConsoleCmd(opts *globalOptions) *cobra.Command {
+	var destination string
+	var open bool
+	cmd := &cobra.Command{
+		Use:   "console",
+		Short: "Print or open an AWS console sign-in URL for the role",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			p, err := opts.resolveProfile()
+			if err != nil {
+				return err
+			}
+
+			a, c, err := opts.newAuth(p)
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := opts.context(cmd)
+			defer cancel()
+			if _, err := a.SAMLAuthContext(ctx); err != nil {
+				return err
+			}
+
+			v, err := c.Value()
+			if err != nil {
+				return err
+			}
+
+			part, err := resolvePartition(p)
+			if err != nil {
+				return err
+			}
+			federationURL := p.FederationURL
+			if federationURL == "" {
+				federationURL = part.FederationURL
+			}
+
+			con := console.New(federationURL, part.ConsoleURL)
+			token, err := con.SigninToken(v)
+			if err != nil {
+				return err
+			}
+
+			u := con.LoginURL(token, destination)
+			if open {
+				return console.Open(u)
+			}
+
+			fmt.Println(u)
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&destination, "destination", "", "Console page to open, a URL or a path such as s3/home")
+	cmd.Flags().BoolVar(&open, "open", false, "Open the URL in the default browser")
+	cmd.Flags().StringVar(&opts.flags.FederationURL, "federation-url", "", fmt.Sprintf("Federation endpoint (default %q or the endpoint of the partition)", console.DefaultFederationURL))
+
+	return cmd
+}
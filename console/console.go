@@ -0,0 +1,123 @@
+package console
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/walkersumida/aws-sso-google/credential"
+	"github.com/walkersumida/aws-sso-google/path"
+)
+
+const (
+	DefaultFederationURL = "https://signin.aws.amazon.com/federation"
+	DefaultConsoleURL    = "https://console.aws.amazon.com/"
+)
+
+// Console builds AWS console sign-in URLs from temporary credentials.
+// https://docs.aws.amazon.com/IAM/latest/UserGuide/id_roles_providers_enable-console-custom-url.html
+type Console struct {
+	ConsoleURL    string
+	FederationURL string
+	HTTPClient    *http.Client
+	Issuer        string
+}
+
+func New(federationURL, consoleURL string) *Console {
+	if federationURL == "" {
+		federationURL = DefaultFederationURL
+	}
+	if consoleURL == "" {
+		consoleURL = DefaultConsoleURL
+	}
+
+	return &Console{
+		ConsoleURL:    consoleURL,
+		FederationURL: federationURL,
+		HTTPClient:    &http.Client{Timeout: 30 * time.Second},
+		Issuer:        path.AppName,
+	}
+}
+
+// SigninToken exchanges the credentials for a sign-in token.
+func (c *Console) SigninToken(v *credential.Value) (string, error) {
+	session, err := json.Marshal(struct {
+		SessionID    string `json:"sessionId"`
+		SessionKey   string `json:"sessionKey"`
+		SessionToken string `json:"sessionToken"`
+	}{
+		SessionID:    v.AccessKeyID,
+		SessionKey:   v.SecretAccessKey,
+		SessionToken: v.SessionToken,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	q := url.Values{}
+	q.Set("Action", "getSigninToken")
+	q.Set("Session", string(session))
+
+	res, err := c.HTTPClient.Get(c.FederationURL + "?" + q.Encode())
+	if err != nil {
+		return "", fmt.Errorf("could not get signin token: %w", err)
+	}
+	defer func() { _ = res.Body.Close() }()
+
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("could not get signin token: %s", res.Status)
+	}
+
+	var body struct {
+		SigninToken string `json:"SigninToken"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("could not decode signin token: %w", err)
+	}
+	if body.SigninToken == "" {
+		return "", fmt.Errorf("could not find signin token in response")
+	}
+
+	return body.SigninToken, nil
+}
+
+// LoginURL returns the URL signing in to the console with the token.
+// destination is either a URL or a path under the console URL such as "s3/home".
+func (c *Console) LoginURL(token, destination string) string {
+	if !strings.HasPrefix(destination, "https://") && !strings.HasPrefix(destination, "http://") {
+		destination = strings.TrimSuffix(c.ConsoleURL, "/") + "/" + strings.TrimPrefix(destination, "/")
+	}
+
+	q := url.Values{}
+	q.Set("Action", "login")
+	q.Set("Issuer", c.Issuer)
+	q.Set("Destination", destination)
+	q.Set("SigninToken", token)
+
+	return c.FederationURL + "?" + q.Encode()
+}
+
+// Open opens the URL in the default browser.
+func Open(u string) error {
+	var cmd *exec.Cmd
+	// #nosec G204 -- the URL is passed as a single argument
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", u)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", u)
+	default:
+		cmd = exec.Command("xdg-open", u)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("could not open browser: %w", err)
+	}
+
+	return cmd.Process.Release()
+}
@@ -0,0 +1,111 @@
+package console_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/walkersumida/aws-sso-google/console"
+	"github.com/walkersumida/aws-sso-google/credential"
+)
+
+func TestSigninToken(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("Action") != "getSigninToken" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		var session map[string]string
+		if err := json.Unmarshal([]byte(r.URL.Query().Get("Session")), &session); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if session["sessionId"] != "access-key-id" || session["sessionKey"] != "secret-access-key" || session["sessionToken"] != "session-token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		_, _ = w.Write([]byte(`{"SigninToken":"token"}`))
+	}))
+	defer srv.Close()
+
+	c := console.New(srv.URL, "")
+	got, err := c.SigninToken(&credential.Value{
+		AccessKeyID:     "access-key-id",
+		SecretAccessKey: "secret-access-key",
+		SessionToken:    "session-token",
+		Expiration:      time.Now().Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if diff := cmp.Diff("token", got); diff != "" {
+		t.Errorf("mismatch (-want +got): ¥n%s", diff)
+	}
+}
+
+func TestSigninTokenError(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	c := console.New(srv.URL, "")
+	if _, err := c.SigninToken(&credential.Value{}); err == nil {
+		t.Error("want error, got nil")
+	}
+}
+
+func TestLoginURL(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		giveDestination string
+		wantDestination string
+	}{
+		"when destination is empty": {
+			giveDestination: "",
+			wantDestination: "https://console.aws.amazon.com/",
+		},
+		"when destination is a path": {
+			giveDestination: "s3/home",
+			wantDestination: "https://console.aws.amazon.com/s3/home",
+		},
+		"when destination is a URL": {
+			giveDestination: "https://us-east-1.console.aws.amazon.com/ec2/home",
+			wantDestination: "https://us-east-1.console.aws.amazon.com/ec2/home",
+		},
+	}
+	for name, tt := range tests {
+		tt, name := tt, name
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			c := console.New("", "")
+			u, err := url.Parse(c.LoginURL("token", tt.giveDestination))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			want := url.Values{
+				"Action":      {"login"},
+				"Destination": {tt.wantDestination},
+				"Issuer":      {"aws-sso-google"},
+				"SigninToken": {"token"},
+			}
+			if diff := cmp.Diff(want, u.Query()); diff != "" {
+				t.Errorf("mismatch (-want +got): ¥n%s", diff)
+			}
+		})
+	}
+}
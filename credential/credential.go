@@ -7,9 +7,7 @@ import (
 	"os"
 	"time"
 
-	"github.com/walkersumida/aws-sso-google/path"
 	"golang.org/x/term"
-	"gopkg.in/ini.v1"
 )
 
 type Credentialer interface {
@@ -17,10 +15,12 @@ type Credentialer interface {
 	SetExpiration(*time.Time)
 	SetSecretAccessKey(*string)
 	SetSessionToken(*string)
+	SetRoleArn(string)
 	Load() error
 	IsExpired() bool
 	Save() error
 	Output() (string, error)
+	Value() (*Value, error)
 }
 
 type Credential struct {
@@ -29,13 +29,24 @@ type Credential struct {
 	AwsProfile      string
 	SecretAccessKey *string
 	SessionToken    *string
+	RoleArn         string
+	Storage         Storage
+
+	// RefreshSkew treats credentials expiring within the duration as expired
+	// so that they are refreshed before the aws cli uses them.
+	RefreshSkew time.Duration
+	// MinValidity is the minimum remaining validity required for credentials.
+	MinValidity time.Duration
+	// Now returns the current time. time.Now is used if nil.
+	Now func() time.Time
 }
 
 var _ Credentialer = &Credential{}
 
-func New(awsProfile string) *Credential {
+func New(awsProfile string, storage Storage) *Credential {
 	return &Credential{
 		AwsProfile: awsProfile,
+		Storage:    storage,
 	}
 }
 
@@ -55,104 +66,74 @@ func (c *Credential) SetSessionToken(sessionToken *string) {
 	c.SessionToken = sessionToken
 }
 
-func (c *Credential) Load() error {
-	p, err := path.CredentialsFile()
-	if err != nil {
-		return err
-	}
-
-	exists, err := path.Exists(p)
-	if err != nil {
-		return err
-	}
-
-	if !exists {
-		cpath, err := path.CacheDirForApp()
-		if err != nil {
-			return err
-		}
-
-		folderExists, err := path.Exists(cpath)
-		if err != nil {
-			return err
-		}
-
-		if !folderExists {
-			err = path.CreateCacheDirForApp()
-			if err != nil {
-				return err
-			}
-		}
-
-		_, err = os.Create(p)
-		if err != nil {
-			return err
-		}
-
-		return nil
-	}
+func (c *Credential) SetRoleArn(roleArn string) {
+	c.RoleArn = roleArn
+}
 
-	cfg, err := ini.Load(p)
+func (c *Credential) Load() error {
+	v, err := c.Storage.Load(c.AwsProfile)
 	if err != nil {
 		return err
 	}
-
-	section := cfg.Section(c.AwsProfile)
-
-	c.SetAccessKeyID(ptrString(section.Key("aws_access_key_id").Value()))
-	c.SetSecretAccessKey(ptrString(section.Key("aws_secret_access_key").Value()))
-	c.SetSessionToken(ptrString(section.Key("aws_session_token").Value()))
-
-	exp := section.Key("aws_session_expiration").Value()
-	if exp == "" {
+	if v == nil {
 		return nil
 	}
 
-	parsedExp, err := time.Parse(time.RFC3339, exp)
-	if err != nil {
-		return err
-	}
-
-	c.SetExpiration(&parsedExp)
+	c.SetAccessKeyID(ptrString(v.AccessKeyID))
+	c.SetSecretAccessKey(ptrString(v.SecretAccessKey))
+	c.SetSessionToken(ptrString(v.SessionToken))
+	c.SetExpiration(&v.Expiration)
+	c.SetRoleArn(v.RoleArn)
 
 	return nil
 }
 
+// IsExpired reports whether the credentials expire within
+// the larger of RefreshSkew and MinValidity.
 func (c *Credential) IsExpired() bool {
 	if c.Expiration == nil {
 		return true
 	}
 
-	return time.Now().After(*c.Expiration)
+	margin := c.RefreshSkew
+	if c.MinValidity > margin {
+		margin = c.MinValidity
+	}
+
+	return !c.now().Add(margin).Before(*c.Expiration)
 }
 
-func (c *Credential) Save() error {
-	err := c.validate()
-	if err != nil {
-		return err
+func (c *Credential) now() time.Time {
+	if c.Now != nil {
+		return c.Now()
 	}
 
-	p, err := path.CredentialsFile()
-	if err != nil {
-		return err
-	}
+	return time.Now()
+}
 
-	cfg, err := ini.Load(p)
+func (c *Credential) Save() error {
+	v, err := c.Value()
 	if err != nil {
 		return err
 	}
 
-	cfg.Section(c.AwsProfile).Key("aws_access_key_id").SetValue(*c.AccessKeyID)
-	cfg.Section(c.AwsProfile).Key("aws_secret_access_key").SetValue(*c.SecretAccessKey)
-	cfg.Section(c.AwsProfile).Key("aws_session_token").SetValue(*c.SessionToken)
-	cfg.Section(c.AwsProfile).Key("aws_session_expiration").SetValue(c.Expiration.Format(time.RFC3339))
+	return c.Storage.Save(c.AwsProfile, v)
+}
 
-	err = cfg.SaveTo(p)
+// Value returns a copy of the credentials.
+func (c *Credential) Value() (*Value, error) {
+	err := c.validate()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return nil
+	return &Value{
+		AccessKeyID:     *c.AccessKeyID,
+		SecretAccessKey: *c.SecretAccessKey,
+		SessionToken:    *c.SessionToken,
+		Expiration:      *c.Expiration,
+		RoleArn:         c.RoleArn,
+	}, nil
 }
 
 // Output returns the credentials in JSON format.
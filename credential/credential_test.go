@@ -0,0 +1,83 @@
+package credential_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/walkersumida/aws-sso-google/credential"
+)
+
+func TestIsExpired(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := map[string]struct {
+		giveExpiration  *time.Time
+		giveRefreshSkew time.Duration
+		giveMinValidity time.Duration
+		want            bool
+	}{
+		"when expiration is not set": {
+			giveExpiration: nil,
+			want:           true,
+		},
+		"when expired": {
+			giveExpiration: toPointer(now.Add(-time.Second)),
+			want:           true,
+		},
+		"when expiring now": {
+			giveExpiration: toPointer(now),
+			want:           true,
+		},
+		"when not expired and no skew": {
+			giveExpiration: toPointer(now.Add(time.Second)),
+			want:           false,
+		},
+		"when expiring within the refresh skew": {
+			giveExpiration:  toPointer(now.Add(4 * time.Minute)),
+			giveRefreshSkew: 5 * time.Minute,
+			want:            true,
+		},
+		"when expiring after the refresh skew": {
+			giveExpiration:  toPointer(now.Add(6 * time.Minute)),
+			giveRefreshSkew: 5 * time.Minute,
+			want:            false,
+		},
+		"when expiring within the min validity": {
+			giveExpiration:  toPointer(now.Add(30 * time.Minute)),
+			giveRefreshSkew: 5 * time.Minute,
+			giveMinValidity: time.Hour,
+			want:            true,
+		},
+		"when expiring after the min validity": {
+			giveExpiration:  toPointer(now.Add(2 * time.Hour)),
+			giveRefreshSkew: 5 * time.Minute,
+			giveMinValidity: time.Hour,
+			want:            false,
+		},
+	}
+	for name, tt := range tests {
+		tt, name := tt, name
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			c := credential.New("example", credential.NewFileStorage())
+			c.SetExpiration(tt.giveExpiration)
+			c.RefreshSkew = tt.giveRefreshSkew
+			c.MinValidity = tt.giveMinValidity
+			c.Now = func() time.Time {
+				return now
+			}
+
+			if diff := cmp.Diff(tt.want, c.IsExpired()); diff != "" {
+				t.Errorf("mismatch (-want +got): ¥n%s", diff)
+			}
+		})
+	}
+}
+
+func toPointer[T comparable](v T) *T {
+	return &v
+}
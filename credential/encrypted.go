@@ -0,0 +1,204 @@
+package credential
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"filippo.io/age"
+	"github.com/walkersumida/aws-sso-google/path"
+	"golang.org/x/term"
+	"gopkg.in/ini.v1"
+)
+
+// PassphraseEnv is the environment variable holding the passphrase
+// of the encrypted file storage.
+const PassphraseEnv = "AWS_SSO_GOOGLE_PASSPHRASE"
+
+// scryptWorkFactor keeps decryption well under a second because
+// the file is decrypted on every credential_process invocation.
+const scryptWorkFactor = 16
+
+// EncryptedFileStorage stores credentials in an INI file encrypted
+// with age using a passphrase.
+type EncryptedFileStorage struct {
+	Path       string // path.EncryptedCredentialsFile() if empty
+	Passphrase func() (string, error)
+
+	passphrase string
+}
+
+var _ Storage = &EncryptedFileStorage{}
+
+func NewEncryptedFileStorage(passphrase func() (string, error)) *EncryptedFileStorage {
+	return &EncryptedFileStorage{
+		Passphrase: passphrase,
+	}
+}
+
+func (s *EncryptedFileStorage) Load(profile string) (*Value, error) {
+	cfg, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+
+	return readSection(cfg, profile)
+}
+
+func (s *EncryptedFileStorage) Save(profile string, v *Value) error {
+	cfg, err := s.load()
+	if err != nil {
+		return err
+	}
+
+	writeSection(cfg, profile, v)
+
+	return s.save(cfg)
+}
+
+func (s *EncryptedFileStorage) Delete(profile string) error {
+	cfg, err := s.load()
+	if err != nil {
+		return err
+	}
+	if !cfg.HasSection(profile) {
+		return nil
+	}
+
+	cfg.DeleteSection(profile)
+
+	return s.save(cfg)
+}
+
+func (s *EncryptedFileStorage) Profiles() ([]string, error) {
+	cfg, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+
+	return sectionNames(cfg), nil
+}
+
+func (s *EncryptedFileStorage) load() (*ini.File, error) {
+	p, err := s.path()
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := os.ReadFile(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return ini.Empty(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	passphrase, err := s.getPassphrase()
+	if err != nil {
+		return nil, err
+	}
+
+	identity, err := age.NewScryptIdentity(passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := age.Decrypt(bytes.NewReader(b), identity)
+	if err != nil {
+		return nil, fmt.Errorf("could not decrypt credentials: %w", err)
+	}
+
+	plain, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("could not decrypt credentials: %w", err)
+	}
+
+	return ini.Load(plain)
+}
+
+func (s *EncryptedFileStorage) save(cfg *ini.File) error {
+	p, err := s.path()
+	if err != nil {
+		return err
+	}
+
+	passphrase, err := s.getPassphrase()
+	if err != nil {
+		return err
+	}
+
+	recipient, err := age.NewScryptRecipient(passphrase)
+	if err != nil {
+		return err
+	}
+	recipient.SetWorkFactor(scryptWorkFactor)
+
+	var buf bytes.Buffer
+	w, err := age.Encrypt(&buf, recipient)
+	if err != nil {
+		return fmt.Errorf("could not encrypt credentials: %w", err)
+	}
+	if _, err := cfg.WriteTo(w); err != nil {
+		return fmt.Errorf("could not encrypt credentials: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("could not encrypt credentials: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(p), 0700); err != nil {
+		return err
+	}
+
+	return os.WriteFile(p, buf.Bytes(), 0600)
+}
+
+// getPassphrase asks for the passphrase only once.
+func (s *EncryptedFileStorage) getPassphrase() (string, error) {
+	if s.passphrase != "" {
+		return s.passphrase, nil
+	}
+
+	p, err := s.Passphrase()
+	if err != nil {
+		return "", err
+	}
+	if p == "" {
+		return "", errors.New("passphrase must not be empty")
+	}
+	s.passphrase = p
+
+	return p, nil
+}
+
+func (s *EncryptedFileStorage) path() (string, error) {
+	if s.Path != "" {
+		return s.Path, nil
+	}
+
+	return path.EncryptedCredentialsFile()
+}
+
+// Passphrase returns the passphrase from the environment variable or
+// asks for it on the terminal.
+func Passphrase() (string, error) {
+	if p := os.Getenv(PassphraseEnv); p != "" {
+		return p, nil
+	}
+
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", fmt.Errorf("passphrase must be set in %s", PassphraseEnv)
+	}
+
+	_, _ = fmt.Fprint(os.Stderr, "Passphrase for credentials: ")
+	b, err := term.ReadPassword(fd)
+	_, _ = fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("could not read passphrase: %w", err)
+	}
+
+	return string(b), nil
+}
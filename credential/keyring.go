@@ -0,0 +1,143 @@
+package credential
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/walkersumida/aws-sso-google/path"
+	"github.com/zalando/go-keyring"
+)
+
+// KeyringStorage stores credentials in the OS keyring
+// (Secret Service on Linux, Keychain on macOS, Credential Manager on Windows).
+type KeyringStorage struct {
+	Service string // path.AppName if empty
+}
+
+var _ Storage = &KeyringStorage{}
+
+func NewKeyringStorage() *KeyringStorage {
+	return &KeyringStorage{}
+}
+
+func (s *KeyringStorage) Load(profile string) (*Value, error) {
+	secret, err := keyring.Get(s.service(), profile)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not get credentials from keyring: %w", err)
+	}
+
+	v := &Value{}
+	if err := json.Unmarshal([]byte(secret), v); err != nil {
+		return nil, fmt.Errorf("could not unmarshal credentials from keyring: %w", err)
+	}
+
+	return v, nil
+}
+
+func (s *KeyringStorage) Save(profile string, v *Value) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	if err := keyring.Set(s.service(), profile, string(b)); err != nil {
+		return fmt.Errorf("could not set credentials to keyring: %w", err)
+	}
+
+	return s.addProfile(profile)
+}
+
+func (s *KeyringStorage) Delete(profile string) error {
+	err := keyring.Delete(s.service(), profile)
+	if err != nil && !errors.Is(err, keyring.ErrNotFound) {
+		return fmt.Errorf("could not delete credentials from keyring: %w", err)
+	}
+
+	return s.removeProfile(profile)
+}
+
+// keyringProfilesUser is the keyring entry listing the saved profiles,
+// as keyrings cannot be enumerated.
+const keyringProfilesUser = "#profiles"
+
+// Profiles returns the profiles saved since the list was introduced.
+func (s *KeyringStorage) Profiles() ([]string, error) {
+	v, err := keyring.Get(s.service(), keyringProfilesUser)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not get profiles from keyring: %w", err)
+	}
+
+	var profiles []string
+	if err := json.Unmarshal([]byte(v), &profiles); err != nil {
+		return nil, fmt.Errorf("could not unmarshal profiles from keyring: %w", err)
+	}
+
+	return profiles, nil
+}
+
+func (s *KeyringStorage) addProfile(profile string) error {
+	profiles, err := s.Profiles()
+	if err != nil {
+		return err
+	}
+	if slices.Contains(profiles, profile) {
+		return nil
+	}
+
+	profiles = append(profiles, profile)
+	sort.Strings(profiles)
+
+	return s.saveProfiles(profiles)
+}
+
+func (s *KeyringStorage) removeProfile(profile string) error {
+	profiles, err := s.Profiles()
+	if err != nil {
+		return err
+	}
+	i := slices.Index(profiles, profile)
+	if i < 0 {
+		return nil
+	}
+
+	profiles = slices.Delete(profiles, i, i+1)
+	if len(profiles) == 0 {
+		err := keyring.Delete(s.service(), keyringProfilesUser)
+		if err != nil && !errors.Is(err, keyring.ErrNotFound) {
+			return fmt.Errorf("could not delete profiles from keyring: %w", err)
+		}
+		return nil
+	}
+
+	return s.saveProfiles(profiles)
+}
+
+func (s *KeyringStorage) saveProfiles(profiles []string) error {
+	b, err := json.Marshal(profiles)
+	if err != nil {
+		return err
+	}
+
+	if err := keyring.Set(s.service(), keyringProfilesUser, string(b)); err != nil {
+		return fmt.Errorf("could not set profiles to keyring: %w", err)
+	}
+
+	return nil
+}
+
+func (s *KeyringStorage) service() string {
+	if s.Service != "" {
+		return s.Service
+	}
+
+	return path.AppName
+}
@@ -37,12 +37,18 @@ var _ credential.Credentialer = &CredentialerMock{}
 //			SetExpirationFunc: func(timeMoqParam *time.Time)  {
 //				panic("mock out the SetExpiration method")
 //			},
+//			SetRoleArnFunc: func(s string)  {
+//				panic("mock out the SetRoleArn method")
+//			},
 //			SetSecretAccessKeyFunc: func(s *string)  {
 //				panic("mock out the SetSecretAccessKey method")
 //			},
 //			SetSessionTokenFunc: func(s *string)  {
 //				panic("mock out the SetSessionToken method")
 //			},
+//			ValueFunc: func() (*credential.Value, error) {
+//				panic("mock out the Value method")
+//			},
 //		}
 //
 //		// use mockedCredentialer in code that requires credential.Credentialer
@@ -68,12 +74,18 @@ type CredentialerMock struct {
 	// SetExpirationFunc mocks the SetExpiration method.
 	SetExpirationFunc func(timeMoqParam *time.Time)
 
+	// SetRoleArnFunc mocks the SetRoleArn method.
+	SetRoleArnFunc func(s string)
+
 	// SetSecretAccessKeyFunc mocks the SetSecretAccessKey method.
 	SetSecretAccessKeyFunc func(s *string)
 
 	// SetSessionTokenFunc mocks the SetSessionToken method.
 	SetSessionTokenFunc func(s *string)
 
+	// ValueFunc mocks the Value method.
+	ValueFunc func() (*credential.Value, error)
+
 	// calls tracks calls to the methods.
 	calls struct {
 		// IsExpired holds details about calls to the IsExpired method.
@@ -98,6 +110,11 @@ type CredentialerMock struct {
 			// TimeMoqParam is the timeMoqParam argument value.
 			TimeMoqParam *time.Time
 		}
+		// SetRoleArn holds details about calls to the SetRoleArn method.
+		SetRoleArn []struct {
+			// S is the s argument value.
+			S string
+		}
 		// SetSecretAccessKey holds details about calls to the SetSecretAccessKey method.
 		SetSecretAccessKey []struct {
 			// S is the s argument value.
@@ -108,6 +125,9 @@ type CredentialerMock struct {
 			// S is the s argument value.
 			S *string
 		}
+		// Value holds details about calls to the Value method.
+		Value []struct {
+		}
 	}
 	lockIsExpired          sync.RWMutex
 	lockLoad               sync.RWMutex
@@ -115,8 +135,10 @@ type CredentialerMock struct {
 	lockSave               sync.RWMutex
 	lockSetAccessKeyID     sync.RWMutex
 	lockSetExpiration      sync.RWMutex
+	lockSetRoleArn         sync.RWMutex
 	lockSetSecretAccessKey sync.RWMutex
 	lockSetSessionToken    sync.RWMutex
+	lockValue              sync.RWMutex
 }
 
 // IsExpired calls IsExpiredFunc.
@@ -291,6 +313,38 @@ func (mock *CredentialerMock) SetExpirationCalls() []struct {
 	return calls
 }
 
+// SetRoleArn calls SetRoleArnFunc.
+func (mock *CredentialerMock) SetRoleArn(s string) {
+	if mock.SetRoleArnFunc == nil {
+		panic("CredentialerMock.SetRoleArnFunc: method is nil but Credentialer.SetRoleArn was just called")
+	}
+	callInfo := struct {
+		S string
+	}{
+		S: s,
+	}
+	mock.lockSetRoleArn.Lock()
+	mock.calls.SetRoleArn = append(mock.calls.SetRoleArn, callInfo)
+	mock.lockSetRoleArn.Unlock()
+	mock.SetRoleArnFunc(s)
+}
+
+// SetRoleArnCalls gets all the calls that were made to SetRoleArn.
+// Check the length with:
+//
+//	len(mockedCredentialer.SetRoleArnCalls())
+func (mock *CredentialerMock) SetRoleArnCalls() []struct {
+	S string
+} {
+	var calls []struct {
+		S string
+	}
+	mock.lockSetRoleArn.RLock()
+	calls = mock.calls.SetRoleArn
+	mock.lockSetRoleArn.RUnlock()
+	return calls
+}
+
 // SetSecretAccessKey calls SetSecretAccessKeyFunc.
 func (mock *CredentialerMock) SetSecretAccessKey(s *string) {
 	if mock.SetSecretAccessKeyFunc == nil {
@@ -354,3 +408,30 @@ func (mock *CredentialerMock) SetSessionTokenCalls() []struct {
 	mock.lockSetSessionToken.RUnlock()
 	return calls
 }
+
+// Value calls ValueFunc.
+func (mock *CredentialerMock) Value() (*credential.Value, error) {
+	if mock.ValueFunc == nil {
+		panic("CredentialerMock.ValueFunc: method is nil but Credentialer.Value was just called")
+	}
+	callInfo := struct {
+	}{}
+	mock.lockValue.Lock()
+	mock.calls.Value = append(mock.calls.Value, callInfo)
+	mock.lockValue.Unlock()
+	return mock.ValueFunc()
+}
+
+// ValueCalls gets all the calls that were made to Value.
+// Check the length with:
+//
+//	len(mockedCredentialer.ValueCalls())
+func (mock *CredentialerMock) ValueCalls() []struct {
+} {
+	var calls []struct {
+	}
+	mock.lockValue.RLock()
+	calls = mock.calls.Value
+	mock.lockValue.RUnlock()
+	return calls
+}
@@ -0,0 +1,228 @@
+package credential
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/walkersumida/aws-sso-google/path"
+	"gopkg.in/ini.v1"
+)
+
+// Storage stores the credentials of profiles.
+type Storage interface {
+	// Load returns the stored value of the profile, or nil if there is none.
+	Load(profile string) (*Value, error)
+	Save(profile string, v *Value) error
+	// Delete removes the stored value of the profile, if any.
+	Delete(profile string) error
+	// Profiles returns the names of the stored profiles in order.
+	Profiles() ([]string, error)
+}
+
+// Value is the credentials stored for a profile.
+type Value struct {
+	AccessKeyID     string    `json:"AccessKeyId"`
+	SecretAccessKey string    `json:"SecretAccessKey"`
+	SessionToken    string    `json:"SessionToken"`
+	Expiration      time.Time `json:"Expiration"`
+
+	// RoleArn is the role the credentials are for. It is empty for
+	// credentials saved before it was recorded.
+	RoleArn string `json:"RoleArn,omitempty"`
+}
+
+const (
+	StorageFile          = "file"
+	StorageKeyring       = "keyring"
+	StorageEncryptedFile = "encrypted-file"
+)
+
+// NewStorage returns the storage of the kind.
+// The plaintext file storage is used if kind is empty.
+func NewStorage(kind string) (Storage, error) {
+	switch kind {
+	case "", StorageFile:
+		return NewFileStorage(), nil
+	case StorageKeyring:
+		return NewKeyringStorage(), nil
+	case StorageEncryptedFile:
+		return NewEncryptedFileStorage(Passphrase), nil
+	default:
+		return nil, fmt.Errorf("unknown credential storage: %s", kind)
+	}
+}
+
+// FileStorage stores credentials in a plaintext INI file.
+type FileStorage struct {
+	Path string // path.CredentialsFile() if empty
+}
+
+var _ Storage = &FileStorage{}
+
+func NewFileStorage() *FileStorage {
+	return &FileStorage{}
+}
+
+func (s *FileStorage) Load(profile string) (*Value, error) {
+	p, err := s.path()
+	if err != nil {
+		return nil, err
+	}
+
+	exists, err := path.Exists(p)
+	if err != nil {
+		return nil, err
+	}
+
+	if !exists {
+		if err := createFile(p); err != nil {
+			return nil, err
+		}
+
+		return nil, nil
+	}
+
+	cfg, err := ini.Load(p)
+	if err != nil {
+		return nil, err
+	}
+
+	return readSection(cfg, profile)
+}
+
+func (s *FileStorage) Save(profile string, v *Value) error {
+	p, err := s.path()
+	if err != nil {
+		return err
+	}
+
+	cfg, err := ini.Load(p)
+	if err != nil {
+		return err
+	}
+
+	writeSection(cfg, profile, v)
+
+	return cfg.SaveTo(p)
+}
+
+func (s *FileStorage) Delete(profile string) error {
+	p, err := s.path()
+	if err != nil {
+		return err
+	}
+
+	exists, err := path.Exists(p)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return nil
+	}
+
+	cfg, err := ini.Load(p)
+	if err != nil {
+		return err
+	}
+	if !cfg.HasSection(profile) {
+		return nil
+	}
+
+	cfg.DeleteSection(profile)
+
+	return cfg.SaveTo(p)
+}
+
+func (s *FileStorage) Profiles() ([]string, error) {
+	p, err := s.path()
+	if err != nil {
+		return nil, err
+	}
+
+	exists, err := path.Exists(p)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, nil
+	}
+
+	cfg, err := ini.Load(p)
+	if err != nil {
+		return nil, err
+	}
+
+	return sectionNames(cfg), nil
+}
+
+func (s *FileStorage) path() (string, error) {
+	if s.Path != "" {
+		return s.Path, nil
+	}
+
+	return path.CredentialsFile()
+}
+
+// createFile creates an empty file and its directory if they do not exist.
+func createFile(p string) error {
+	if err := os.MkdirAll(filepath.Dir(p), 0700); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(p, os.O_RDONLY|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+
+	return f.Close()
+}
+
+func readSection(cfg *ini.File, profile string) (*Value, error) {
+	section := cfg.Section(profile)
+
+	exp := section.Key("aws_session_expiration").Value()
+	if exp == "" {
+		return nil, nil
+	}
+
+	parsedExp, err := time.Parse(time.RFC3339, exp)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Value{
+		AccessKeyID:     section.Key("aws_access_key_id").Value(),
+		SecretAccessKey: section.Key("aws_secret_access_key").Value(),
+		SessionToken:    section.Key("aws_session_token").Value(),
+		Expiration:      parsedExp,
+		RoleArn:         section.Key("aws_role_arn").Value(),
+	}, nil
+}
+
+func writeSection(cfg *ini.File, profile string, v *Value) {
+	cfg.Section(profile).Key("aws_access_key_id").SetValue(v.AccessKeyID)
+	cfg.Section(profile).Key("aws_secret_access_key").SetValue(v.SecretAccessKey)
+	cfg.Section(profile).Key("aws_session_token").SetValue(v.SessionToken)
+	cfg.Section(profile).Key("aws_session_expiration").SetValue(v.Expiration.Format(time.RFC3339))
+	if v.RoleArn != "" {
+		cfg.Section(profile).Key("aws_role_arn").SetValue(v.RoleArn)
+	} else {
+		cfg.Section(profile).DeleteKey("aws_role_arn")
+	}
+}
+
+// sectionNames returns the sections holding credentials in order.
+func sectionNames(cfg *ini.File) []string {
+	var names []string
+	for _, section := range cfg.Sections() {
+		if section.HasKey("aws_session_expiration") {
+			names = append(names, section.Name())
+		}
+	}
+	sort.Strings(names)
+
+	return names
+}
@@ -0,0 +1,119 @@
+package credential_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/walkersumida/aws-sso-google/credential"
+	"github.com/zalando/go-keyring"
+)
+
+func TestStorage(t *testing.T) {
+	keyring.MockInit()
+
+	tests := map[string]struct {
+		newStorage func(dir string) credential.Storage
+	}{
+		"file": {
+			newStorage: func(dir string) credential.Storage {
+				return &credential.FileStorage{Path: filepath.Join(dir, "credentials")}
+			},
+		},
+		"encrypted file": {
+			newStorage: func(dir string) credential.Storage {
+				s := credential.NewEncryptedFileStorage(func() (string, error) {
+					return "passphrase", nil
+				})
+				s.Path = filepath.Join(dir, "credentials.age")
+				return s
+			},
+		},
+		"keyring": {
+			newStorage: func(dir string) credential.Storage {
+				return &credential.KeyringStorage{Service: "aws-sso-google-test"}
+			},
+		},
+	}
+	for name, tt := range tests {
+		tt, name := tt, name
+		t.Run(name, func(t *testing.T) {
+			s := tt.newStorage(t.TempDir())
+
+			got, err := s.Load("example")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != nil {
+				t.Fatalf("want nil, got %+v", got)
+			}
+
+			want := &credential.Value{
+				AccessKeyID:     "access-key-id",
+				SecretAccessKey: "secret-access-key",
+				SessionToken:    "session-token",
+				Expiration:      time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+				RoleArn:         "arn:aws:iam::123456789012:role/Admin",
+			}
+			if err := s.Save("example", want); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			got, err = s.Load("example")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("mismatch (-want +got): ¥n%s", diff)
+			}
+
+			profiles, err := s.Profiles()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff([]string{"example"}, profiles); diff != "" {
+				t.Errorf("mismatch (-want +got): ¥n%s", diff)
+			}
+
+			if err := s.Delete("example"); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got, err = s.Load("example")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != nil {
+				t.Errorf("want nil, got %+v", got)
+			}
+			profiles, err = s.Profiles()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(0, len(profiles)); diff != "" {
+				t.Errorf("mismatch (-want +got): ¥n%s", diff)
+			}
+		})
+	}
+}
+
+func TestEncryptedFileStorageWrongPassphrase(t *testing.T) {
+	t.Parallel()
+
+	p := filepath.Join(t.TempDir(), "credentials.age")
+	s := credential.NewEncryptedFileStorage(func() (string, error) {
+		return "passphrase", nil
+	})
+	s.Path = p
+	if err := s.Save("example", &credential.Value{Expiration: time.Now()}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	s = credential.NewEncryptedFileStorage(func() (string, error) {
+		return "wrong", nil
+	})
+	s.Path = p
+	if _, err := s.Load("example"); err == nil {
+		t.Error("want error, got nil")
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/walkersumida/aws-sso-google/shell"
+)
+
+func newEnvCmd(opts *globalOptions) *cobra.Command {
+	var shellName string
+	var unset bool
+	cmd := &cobra.Command{
+		Use:   "env",
+		Short: "Print shell statements exporting the credentials",
+		Long: `Print shell statements exporting the credentials.
+
+  eval "$(aws-sso-google env -p example)"                      # bash, zsh
+  aws-sso-google env -p example | source                       # fish
+  aws-sso-google env -p example --shell powershell | Invoke-Expression`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if shellName == "" {
+				shellName = shell.Detect()
+			}
+			if !shell.IsSupported(shellName) {
+				return fmt.Errorf("unknown shell: %s", shellName)
+			}
+
+			if unset {
+				for _, name := range credentialEnvNames {
+					line, err := shell.Unset(shellName, name)
+					if err != nil {
+						return err
+					}
+					fmt.Println(line)
+				}
+
+				return nil
+			}
+
+			p, err := opts.resolveProfile()
+			if err != nil {
+				return err
+			}
+
+			a, c, err := opts.newAuth(p)
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := opts.context(cmd)
+			defer cancel()
+			if _, err := a.SAMLAuthContext(ctx); err != nil {
+				return err
+			}
+
+			vars, err := credentialEnv(c, p.AwsRegion)
+			if err != nil {
+				return err
+			}
+
+			for _, v := range vars {
+				line, err := shell.Export(shellName, v.name, v.value)
+				if err != nil {
+					return err
+				}
+				fmt.Println(line)
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&shellName, "shell", "", "Shell of the statements (bash, zsh, fish, powershell) (default detected from $SHELL)")
+	cmd.Flags().BoolVar(&unset, "unset", false, "Print statements removing the variables instead")
+
+	return cmd
+}
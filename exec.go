@@ -0,0 +1,112 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/walkersumida/aws-sso-google/credential"
+	"github.com/walkersumida/aws-sso-google/process"
+)
+
+// credentialEnvNames are the names of the variables set by credentialEnv.
+var credentialEnvNames = []string{
+	"AWS_ACCESS_KEY_ID",
+	"AWS_SECRET_ACCESS_KEY",
+	"AWS_SESSION_TOKEN",
+	"AWS_CREDENTIAL_EXPIRATION",
+	"AWS_REGION",
+	"AWS_DEFAULT_REGION",
+}
+
+type envVar struct {
+	name  string
+	value string
+}
+
+func newExecCmd(opts *globalOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "exec [flags] -- command [args...]",
+		Short: "Run a command with the credentials in its environment",
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			p, err := opts.resolveProfile()
+			if err != nil {
+				return err
+			}
+
+			a, c, err := opts.newAuth(p)
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := opts.context(cmd)
+			defer cancel()
+			if _, err := a.SAMLAuthContext(ctx); err != nil {
+				return err
+			}
+
+			vars, err := credentialEnv(c, p.AwsRegion)
+			if err != nil {
+				return err
+			}
+
+			// Errors of the child are its own; only its exit code is propagated.
+			cmd.SilenceErrors = true
+			cmd.SilenceUsage = true
+
+			return execCommand(args, vars)
+		},
+	}
+
+	cmd.Flags().SetInterspersed(false)
+
+	return cmd
+}
+
+// credentialEnv returns the environment variables read by AWS SDKs and the aws cli.
+func credentialEnv(c *credential.Credential, region string) ([]envVar, error) {
+	v, err := c.Value()
+	if err != nil {
+		return nil, err
+	}
+
+	vars := []envVar{
+		{name: "AWS_ACCESS_KEY_ID", value: v.AccessKeyID},
+		{name: "AWS_SECRET_ACCESS_KEY", value: v.SecretAccessKey},
+		{name: "AWS_SESSION_TOKEN", value: v.SessionToken},
+		{name: "AWS_CREDENTIAL_EXPIRATION", value: v.Expiration.Format(time.RFC3339)},
+	}
+	if region != "" {
+		vars = append(vars,
+			envVar{name: "AWS_REGION", value: region},
+			envVar{name: "AWS_DEFAULT_REGION", value: region},
+		)
+	}
+
+	return vars, nil
+}
+
+// execCommand runs the command with vars added to the environment,
+// forwards signals to it and returns its exit code as an exitCodeError.
+func execCommand(args []string, vars []envVar) error {
+	c := exec.Command(args[0], args[1:]...) // #nosec G204 -- running the user's command is the purpose
+	c.Env = os.Environ()
+	for _, v := range vars {
+		c.Env = append(c.Env, v.name+"="+v.value)
+	}
+	c.Stdin = os.Stdin
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+
+	code, err := process.Run(c)
+	if err != nil {
+		return err
+	}
+	if code != 0 {
+		return &exitCodeError{code: code}
+	}
+
+	return nil
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/walkersumida/aws-sso-google/config"
+	"github.com/walkersumida/aws-sso-google/saml"
+)
+
+func newImportMetadataCmd(opts *globalOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "import-metadata <file or URL>",
+		Short: "Configure the IdP from the SAML metadata of the Google SAML app",
+		Long: `Configure the IdP from the SAML metadata of the Google SAML app.
+
+The idpid, entity ID and signing certificate are written to the profile
+set by --aws-profile, or to the defaults of the config file. --sp-id is
+written too if set, as the metadata does not have it.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, cancel := opts.context(cmd)
+			defer cancel()
+			m, err := saml.LoadMetadata(ctx, args[0])
+			if err != nil {
+				return err
+			}
+			idpID, err := m.IDPID()
+			if err != nil {
+				return err
+			}
+
+			p, err := opts.configPath()
+			if err != nil {
+				return err
+			}
+			cfg, err := opts.loadConfig()
+			if err != nil {
+				return err
+			}
+
+			cfg.MergeProfile(opts.awsProfile, config.Profile{
+				IDPCertificate: saml.EncodeCertificates(m.Certificates),
+				IDPEntityID:    m.EntityID,
+				IDPID:          idpID,
+				SpID:           opts.flags.SpID,
+			})
+			if err := cfg.Save(p); err != nil {
+				return err
+			}
+
+			target := "defaults"
+			if opts.awsProfile != "" {
+				target = fmt.Sprintf("profile %q", opts.awsProfile)
+			}
+			_, _ = fmt.Fprintf(os.Stderr, "Imported IdP %s with %d certificate(s) into %s of %s\n", idpID, len(m.Certificates), target, p)
+
+			return nil
+		},
+	}
+
+	return cmd
+}
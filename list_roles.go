@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/walkersumida/aws-sso-google/saml"
+)
+
+func newListRolesCmd(opts *globalOptions) *cobra.Command {
+	var output string
+	cmd := &cobra.Command{
+		Use:   "list-roles",
+		Short: "List the roles and principals in the SAML assertion",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if output != "table" && output != "json" {
+				return fmt.Errorf("unknown output format: %s", output)
+			}
+
+			p, err := opts.resolveProfile()
+			if err != nil {
+				return err
+			}
+			if err := opts.requireOptions(p, "idp-id", "sp-id"); err != nil {
+				return err
+			}
+
+			part, err := resolvePartition(p)
+			if err != nil {
+				return err
+			}
+
+			s, err := newSAML(p, "", part)
+			if err != nil {
+				return err
+			}
+			ctx, cancel := opts.context(cmd)
+			defer cancel()
+			res, err := s.SigninContext(ctx)
+			if err != nil {
+				return err
+			}
+
+			for _, v := range res.Assertion.InvalidRoles {
+				_, _ = fmt.Fprintf(os.Stderr, "Skipped invalid Role attribute value %q\n", v)
+			}
+
+			return printRoles(os.Stdout, res.Roles, output)
+		},
+	}
+
+	cmd.Flags().StringVarP(&output, "output", "o", "table", "Output format (table, json)")
+
+	return cmd
+}
+
+func printRoles(w io.Writer, roles []saml.Role, output string) error {
+	switch output {
+	case "json":
+		if roles == nil {
+			roles = []saml.Role{}
+		}
+		b, err := json.MarshalIndent(roles, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(b))
+		return err
+	case "table":
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(tw, "ROLE ARN\tPRINCIPAL ARN")
+		for _, r := range roles {
+			_, _ = fmt.Fprintf(tw, "%s\t%s\n", r.RoleArn, r.PrincipalArn)
+		}
+		return tw.Flush()
+	default:
+		return fmt.Errorf("unknown output format: %s", output)
+	}
+}
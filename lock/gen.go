@@ -0,0 +1,3 @@
+package lock
+
+//go:generate go run github.com/matryer/moq -pkg mock -out mock/service.go . Locker
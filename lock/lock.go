@@ -0,0 +1,94 @@
+package lock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ErrTimeout is returned when the lock could not be acquired in time.
+var ErrTimeout = errors.New("timed out waiting for lock")
+
+const pollInterval = 100 * time.Millisecond
+
+type Locker interface {
+	Lock() error
+	LockContext(ctx context.Context) error
+	Unlock() error
+}
+
+// FileLock is an advisory lock on a file shared between processes.
+type FileLock struct {
+	Path    string
+	Timeout time.Duration // wait forever if zero
+
+	f *os.File
+}
+
+var _ Locker = &FileLock{}
+
+func New(path string, timeout time.Duration) *FileLock {
+	return &FileLock{
+		Path:    path,
+		Timeout: timeout,
+	}
+}
+
+// Lock blocks until the lock is acquired or the timeout expires.
+func (l *FileLock) Lock() error {
+	return l.LockContext(context.Background())
+}
+
+// LockContext is Lock that also gives up when ctx is done.
+func (l *FileLock) LockContext(ctx context.Context) error {
+	if err := os.MkdirAll(filepath.Dir(l.Path), 0700); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(l.Path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return fmt.Errorf("could not open lock file: %w", err)
+	}
+
+	deadline := time.Now().Add(l.Timeout)
+	for {
+		ok, err := tryLock(f)
+		if err != nil {
+			_ = f.Close()
+			return fmt.Errorf("could not lock file: %w", err)
+		}
+		if ok {
+			l.f = f
+			return nil
+		}
+
+		if l.Timeout > 0 && time.Now().After(deadline) {
+			_ = f.Close()
+			return fmt.Errorf("%w: %s", ErrTimeout, l.Path)
+		}
+
+		select {
+		case <-ctx.Done():
+			_ = f.Close()
+			return ctx.Err()
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+func (l *FileLock) Unlock() error {
+	if l.f == nil {
+		return nil
+	}
+
+	err := unlock(l.f)
+	if cerr := l.f.Close(); err == nil {
+		err = cerr
+	}
+	l.f = nil
+
+	return err
+}
@@ -0,0 +1,58 @@
+package lock_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/walkersumida/aws-sso-google/lock"
+)
+
+func TestFileLock(t *testing.T) {
+	t.Parallel()
+
+	p := filepath.Join(t.TempDir(), "lock")
+
+	l1 := lock.New(p, time.Second)
+	if err := l1.Lock(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	l2 := lock.New(p, 300*time.Millisecond)
+	if err := l2.Lock(); !errors.Is(err, lock.ErrTimeout) {
+		t.Fatalf("want ErrTimeout, got %v", err)
+	}
+
+	if err := l1.Unlock(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := l2.Lock(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := l2.Unlock(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestFileLockContext(t *testing.T) {
+	t.Parallel()
+
+	p := filepath.Join(t.TempDir(), "lock")
+
+	l1 := lock.New(p, 0)
+	if err := l1.Lock(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer func() { _ = l1.Unlock() }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+
+	l2 := lock.New(p, 0)
+	if err := l2.LockContext(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("want context.DeadlineExceeded, got %v", err)
+	}
+}
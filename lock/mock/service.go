@@ -0,0 +1,150 @@
+// Code generated by moq; DO NOT EDIT.
+// github.com/matryer/moq
+
+package mock
+
+import (
+	"context"
+	"github.com/walkersumida/aws-sso-google/lock"
+	"sync"
+)
+
+// Ensure, that LockerMock does implement lock.Locker.
+// If this is not the case, regenerate this file with moq.
+var _ lock.Locker = &LockerMock{}
+
+// LockerMock is a mock implementation of lock.Locker.
+//
+//	func TestSomethingThatUsesLocker(t *testing.T) {
+//
+//		// make and configure a mocked lock.Locker
+//		mockedLocker := &LockerMock{
+//			LockFunc: func() error {
+//				panic("mock out the Lock method")
+//			},
+//			LockContextFunc: func(ctx context.Context) error {
+//				panic("mock out the LockContext method")
+//			},
+//			UnlockFunc: func() error {
+//				panic("mock out the Unlock method")
+//			},
+//		}
+//
+//		// use mockedLocker in code that requires lock.Locker
+//		// and then make assertions.
+//
+//	}
+type LockerMock struct {
+	// LockFunc mocks the Lock method.
+	LockFunc func() error
+
+	// LockContextFunc mocks the LockContext method.
+	LockContextFunc func(ctx context.Context) error
+
+	// UnlockFunc mocks the Unlock method.
+	UnlockFunc func() error
+
+	// calls tracks calls to the methods.
+	calls struct {
+		// Lock holds details about calls to the Lock method.
+		Lock []struct {
+		}
+		// LockContext holds details about calls to the LockContext method.
+		LockContext []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+		}
+		// Unlock holds details about calls to the Unlock method.
+		Unlock []struct {
+		}
+	}
+	lockLock        sync.RWMutex
+	lockLockContext sync.RWMutex
+	lockUnlock      sync.RWMutex
+}
+
+// Lock calls LockFunc.
+func (mock *LockerMock) Lock() error {
+	if mock.LockFunc == nil {
+		panic("LockerMock.LockFunc: method is nil but Locker.Lock was just called")
+	}
+	callInfo := struct {
+	}{}
+	mock.lockLock.Lock()
+	mock.calls.Lock = append(mock.calls.Lock, callInfo)
+	mock.lockLock.Unlock()
+	return mock.LockFunc()
+}
+
+// LockCalls gets all the calls that were made to Lock.
+// Check the length with:
+//
+//	len(mockedLocker.LockCalls())
+func (mock *LockerMock) LockCalls() []struct {
+} {
+	var calls []struct {
+	}
+	mock.lockLock.RLock()
+	calls = mock.calls.Lock
+	mock.lockLock.RUnlock()
+	return calls
+}
+
+// LockContext calls LockContextFunc.
+func (mock *LockerMock) LockContext(ctx context.Context) error {
+	if mock.LockContextFunc == nil {
+		panic("LockerMock.LockContextFunc: method is nil but Locker.LockContext was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+	}{
+		Ctx: ctx,
+	}
+	mock.lockLockContext.Lock()
+	mock.calls.LockContext = append(mock.calls.LockContext, callInfo)
+	mock.lockLockContext.Unlock()
+	return mock.LockContextFunc(ctx)
+}
+
+// LockContextCalls gets all the calls that were made to LockContext.
+// Check the length with:
+//
+//	len(mockedLocker.LockContextCalls())
+func (mock *LockerMock) LockContextCalls() []struct {
+	Ctx context.Context
+} {
+	var calls []struct {
+		Ctx context.Context
+	}
+	mock.lockLockContext.RLock()
+	calls = mock.calls.LockContext
+	mock.lockLockContext.RUnlock()
+	return calls
+}
+
+// Unlock calls UnlockFunc.
+func (mock *LockerMock) Unlock() error {
+	if mock.UnlockFunc == nil {
+		panic("LockerMock.UnlockFunc: method is nil but Locker.Unlock was just called")
+	}
+	callInfo := struct {
+	}{}
+	mock.lockUnlock.Lock()
+	mock.calls.Unlock = append(mock.calls.Unlock, callInfo)
+	mock.lockUnlock.Unlock()
+	return mock.UnlockFunc()
+}
+
+// UnlockCalls gets all the calls that were made to Unlock.
+// Check the length with:
+//
+//	len(mockedLocker.UnlockCalls())
+func (mock *LockerMock) UnlockCalls() []struct {
+} {
+	var calls []struct {
+	}
+	mock.lockUnlock.RLock()
+	calls = mock.calls.Unlock
+	mock.lockUnlock.RUnlock()
+	return calls
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/walkersumida/aws-sso-google/config"
+	"github.com/walkersumida/aws-sso-google/credential"
+	"github.com/walkersumida/aws-sso-google/path"
+	"github.com/walkersumida/aws-sso-google/saml"
+)
+
+func newLogoutCmd(opts *globalOptions) *cobra.Command {
+	var all, browserData bool
+	cmd := &cobra.Command{
+		Use:   "logout",
+		Short: "Remove cached credentials, SAML assertions and browser data",
+		Long: `Remove cached credentials, SAML assertions and browser data.
+
+--aws-profile removes the credentials of the profile and the roles of its
+chain, and the cached SAML assertion of its SAML app. --all removes the
+credential files, the credentials in the keyring and the assertion cache
+with its keys, whatever the config file uses. --browser-data also removes
+the browser profile keeping the Google session.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			switch {
+			case all && opts.awsProfile != "":
+				return errors.New("--aws-profile and --all cannot be used together")
+			case !all && opts.awsProfile == "":
+				return errors.New("either --aws-profile or --all must be set")
+			}
+
+			cfg, err := opts.loadConfig()
+			if err != nil {
+				return err
+			}
+			p := opts.resolveNamedProfile(cfg, opts.awsProfile)
+
+			// Wait for a login in progress so that it does not save
+			// credentials right after they are removed.
+			locker, err := newLocker(p)
+			if err != nil {
+				return err
+			}
+			ctx, cancel := opts.context(cmd)
+			defer cancel()
+			if err := locker.LockContext(ctx); err != nil {
+				return fmt.Errorf("could not acquire lock: %w", err)
+			}
+			defer func() { _ = locker.Unlock() }()
+
+			// Each removal is reported as it is done, so that the output is
+			// exact even if a later one fails.
+			removed := 0
+			report := func(format string, a ...any) {
+				removed++
+				_, _ = fmt.Fprintf(os.Stderr, "Removed "+format+"\n", a...)
+			}
+
+			if all {
+				if err := purgeAll(report); err != nil {
+					return err
+				}
+			} else if err := removeProfile(opts, cfg, p, report); err != nil {
+				return err
+			}
+
+			if browserData {
+				browsers := saml.Browsers
+				if !all {
+					browsers = []string{p.Browser}
+					if p.Browser == "" {
+						browsers = []string{saml.BrowserChromium}
+					}
+				}
+				for _, b := range browsers {
+					dir, err := path.UserDataDirForBrowser(b)
+					if err != nil {
+						return fmt.Errorf("could not get user data dir: %w", err)
+					}
+					exists, err := path.Exists(dir)
+					if err != nil {
+						return err
+					}
+					if !exists {
+						continue
+					}
+					if err := os.RemoveAll(dir); err != nil {
+						return fmt.Errorf("could not remove user data dir: %w", err)
+					}
+					report("browser data of %s (%s)", b, dir)
+				}
+			}
+
+			if removed == 0 {
+				_, _ = fmt.Fprintln(os.Stderr, "Nothing to remove")
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&all, "all", false, "Remove the cached credentials of every profile")
+	cmd.Flags().BoolVar(&browserData, "browser-data", false, "Also remove the browser profile keeping the Google session")
+
+	return cmd
+}
+
+// removeProfile removes the credentials of the profile and its chain from
+// every storage that may hold them, and its cached SAML assertion.
+func removeProfile(opts *globalOptions, cfg *config.Config, p config.Profile, report func(string, ...any)) error {
+	kinds, err := opts.storedKinds(cfg)
+	if err != nil {
+		return err
+	}
+	for _, kind := range kinds {
+		storage, err := credential.NewStorage(kind)
+		if err != nil {
+			return err
+		}
+		names, err := storage.Profiles()
+		if err != nil {
+			return err
+		}
+		for _, name := range names {
+			if profile, _, _ := strings.Cut(name, "#"); profile != opts.awsProfile {
+				continue
+			}
+			if err := storage.Delete(name); err != nil {
+				return err
+			}
+			report("credentials of %s from %s storage", name, kind)
+		}
+	}
+
+	if p.IDPID == "" || p.SpID == "" {
+		return nil
+	}
+	part, err := resolvePartition(p)
+	if err != nil {
+		return err
+	}
+	s, err := newSAML(p, "", part)
+	if err != nil {
+		return err
+	}
+	if s.Cache == nil {
+		return nil
+	}
+	ok, err := s.Cache.Delete(s.CacheKey())
+	if err != nil {
+		return err
+	}
+	if ok {
+		report("cached SAML assertion of idp %s and sp %s", p.IDPID, p.SpID)
+	}
+
+	return nil
+}
+
+// purgeAll removes every backend of credentials and assertions that
+// exists, whether or not the config file uses it, as the config may have
+// changed since they were written.
+func purgeAll(report func(string, ...any)) error {
+	files := []struct {
+		name string
+		path func() (string, error)
+	}{
+		{name: "credential file", path: path.CredentialsFile},
+		{name: "encrypted credential file", path: path.EncryptedCredentialsFile},
+		{name: "SAML assertion cache", path: path.AssertionCacheFile},
+		{name: "SAML assertion cache key", path: path.AssertionCacheKeyFile},
+	}
+	for _, f := range files {
+		p, err := f.path()
+		if err != nil {
+			return err
+		}
+		err = os.Remove(p)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("could not remove %s: %w", f.name, err)
+		}
+		report("%s %s", f.name, p)
+	}
+
+	// Without a Secret Service, e.g. on a server, the keyring cannot be
+	// read, so nothing has been saved in it either.
+	storage := credential.NewKeyringStorage()
+	names, err := storage.Profiles()
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Skipped the keyring, which could not be read: %v\n", err)
+		return nil
+	}
+	for _, name := range names {
+		if err := storage.Delete(name); err != nil {
+			return err
+		}
+		report("credentials of %s from keyring storage", name)
+	}
+
+	key := &saml.KeyringKeyStore{}
+	k, err := key.Load()
+	if err != nil {
+		return err
+	}
+	if k != "" {
+		if err := key.Delete(); err != nil {
+			return err
+		}
+		report("SAML assertion cache key from keyring")
+	}
+
+	return nil
+}
@@ -1,30 +1,45 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/spf13/cobra"
-	"github.com/walkersumida/aws-sso-google/auth"
 	"github.com/walkersumida/aws-sso-google/credential"
 	"github.com/walkersumida/aws-sso-google/saml"
 	"github.com/walkersumida/aws-sso-google/sts"
 )
 
+const (
+	defaultLockTimeout = 10 * time.Minute
+	defaultRefreshSkew = 5 * time.Minute
+)
+
 func run() error {
-	var clean bool
-	var awsSessionDuration int32
-	var awsRegion, awsRoleArn, idpID, awsProfile, spID, username string
+	opts := &globalOptions{}
 	var rootCmd = &cobra.Command{
 		Use:     "aws-sso-google",
 		Version: "0.7.1",
 		Short:   "Acquire AWS STS credentials via Google Workspace SAML in a browser",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			c := credential.New(awsProfile)
-			saml := saml.New(awsRoleArn, idpID, spID, username, clean)
-			sts := sts.New(awsProfile, awsRegion, awsRoleArn, awsSessionDuration)
-			a := auth.New(c, saml, sts)
-			cred, err := a.SAMLAuth()
+			p, err := opts.resolveProfile()
+			if err != nil {
+				return err
+			}
+
+			a, _, err := opts.newAuth(p)
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := opts.context(cmd)
+			defer cancel()
+			cred, err := a.SAMLAuthContext(ctx)
 			if err != nil {
 				return err
 			}
@@ -35,38 +50,82 @@ func run() error {
 		},
 	}
 
-	rootCmd.Flags().BoolVarP(&clean, "clean", "c", false, "Clean browser session")
-	rootCmd.Flags().Int32VarP(&awsSessionDuration, "aws-session-duration", "d", 3600, "AWS session duration in seconds")
-	rootCmd.Flags().StringVarP(&awsProfile, "aws-profile", "p", "", "AWS profile")
-	rootCmd.Flags().StringVarP(&awsRegion, "aws-region", "e", "", "AWS region")
-	rootCmd.Flags().StringVarP(&awsRoleArn, "aws-role-arn", "r", "", "AWS role arn")
-	rootCmd.Flags().StringVarP(&idpID, "idp-id", "i", "", "Google SSO IdP identifier")
-	rootCmd.Flags().StringVarP(&spID, "sp-id", "s", "", "Google SSO SP identifier")
-	rootCmd.Flags().StringVarP(&username, "username", "u", "", "Google Email address")
+	rootCmd.PersistentFlags().StringVar(&opts.flags.AssertionCache, "assertion-cache", "", "Key storage of the encrypted SAML assertion cache (keyring, file, none) (default \"none\")")
+	boolVarP(rootCmd.PersistentFlags(), &opts.flags.Clean, "clean", "c", "Clean browser session")
+	rootCmd.PersistentFlags().StringVar(&opts.flags.Browser, "browser", "", "Browser to sign in with (chromium, firefox, webkit, chrome, msedge) (default \"chromium\")")
+	rootCmd.PersistentFlags().StringVar(&opts.flags.BrowserCDPURL, "browser-cdp-url", "", "Sign in with the running browser at the CDP endpoint, e.g. http://localhost:9222")
+	rootCmd.PersistentFlags().StringVar(&opts.flags.BrowserPath, "browser-path", "", "Executable of the browser to sign in with")
+	rootCmd.PersistentFlags().StringVar(&opts.configFile, "config", "", "Config file (default is $AWS_SSO_GOOGLE_CONFIG or <user config dir>/aws-sso-google.yaml)")
+	rootCmd.PersistentFlags().StringVar(&opts.flags.CredentialStorage, "credential-storage", "", "Storage of cached credentials (file, keyring, encrypted-file) (default \"file\")")
+	rootCmd.PersistentFlags().Int32VarP(&opts.flags.AwsSessionDuration, "aws-session-duration", "d", 0, fmt.Sprintf("AWS session duration in seconds (default SessionDuration in the SAML assertion or %d)", sts.DefaultSessionDuration))
+	rootCmd.PersistentFlags().DurationVar(&opts.flags.LockTimeout, "lock-timeout", 0, fmt.Sprintf("Time to wait for another process signing in (default %s)", defaultLockTimeout))
+	rootCmd.PersistentFlags().DurationVar(&opts.flags.LoginTimeout, "login-timeout", 0, fmt.Sprintf("Time to wait for the user to sign in in the browser (default %s)", saml.DefaultLoginTimeout))
+	rootCmd.PersistentFlags().DurationVar(&opts.flags.MinValidity, "min-validity", 0, "Minimum remaining validity required for credentials")
+	rootCmd.PersistentFlags().DurationVar(&opts.flags.RefreshSkew, "refresh-skew", 0, fmt.Sprintf("Refresh credentials expiring within the duration (default %s)", defaultRefreshSkew))
+	rootCmd.PersistentFlags().StringVarP(&opts.awsProfile, "aws-profile", "p", "", "AWS profile")
+	rootCmd.PersistentFlags().StringVarP(&opts.flags.AwsRegion, "aws-region", "e", "", "AWS region")
+	rootCmd.PersistentFlags().StringVarP(&opts.flags.AwsRoleArn, "aws-role-arn", "r", "", "AWS role arn")
+	boolVarP(rootCmd.PersistentFlags(), &opts.flags.Headless, "headless", "", "Try signing in without a browser window using the saved session first")
+	rootCmd.PersistentFlags().DurationVar(&opts.flags.HeadlessTimeout, "headless-timeout", 0, fmt.Sprintf("Time to wait for the headless sign in before opening a window (default %s)", saml.DefaultHeadlessTimeout))
+	rootCmd.PersistentFlags().StringVar(&opts.flags.IDPCertificate, "idp-certificate", "", "PEM certificate of the IdP to verify the SAML assertion with")
+	rootCmd.PersistentFlags().StringVarP(&opts.flags.IDPID, "idp-id", "i", "", "Google SSO IdP identifier")
+	rootCmd.PersistentFlags().StringVar(&opts.flags.IDPMetadata, "idp-metadata", "", "File or URL of the IdP metadata to verify the SAML assertion with")
+	rootCmd.PersistentFlags().StringVar(&opts.flags.Partition, "partition", "", "AWS partition (aws, aws-cn, aws-us-gov) (default is the partition of the role arn)")
+	rootCmd.PersistentFlags().StringVarP(&opts.flags.SpID, "sp-id", "s", "", "Google SSO SP identifier")
+	rootCmd.PersistentFlags().StringVarP(&opts.flags.Username, "username", "u", "", "Google Email address")
 
-	if err := rootCmd.MarkFlagRequired("aws-profile"); err != nil {
-		return err
-	}
-	if err := rootCmd.MarkFlagRequired("aws-role-arn"); err != nil {
-		return err
-	}
-	if err := rootCmd.MarkFlagRequired("idp-id"); err != nil {
-		return err
-	}
-	if err := rootCmd.MarkFlagRequired("sp-id"); err != nil {
-		return err
-	}
+	rootCmd.PersistentFlags().BoolVar(&opts.saveRole, "save-role", false, "Save the role chosen in the role picker to the config file")
+	rootCmd.PersistentFlags().DurationVar(&opts.timeout, "timeout", 0, "Give up signing in and getting credentials after the duration")
 
-	if err := rootCmd.Execute(); err != nil {
+	rootCmd.AddCommand(newConsoleCmd(opts))
+	rootCmd.AddCommand(newEnvCmd(opts))
+	rootCmd.AddCommand(newExecCmd(opts))
+	rootCmd.AddCommand(newImportMetadataCmd(opts))
+	rootCmd.AddCommand(newListRolesCmd(opts))
+	rootCmd.AddCommand(newLogoutCmd(opts))
+	rootCmd.AddCommand(newRefreshCmd(opts))
+	rootCmd.AddCommand(newStatusCmd(opts))
+
+	// Interrupting cancels the sign in, which closes the browser.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := rootCmd.ExecuteContext(ctx); err != nil {
 		return err
 	}
 
 	return nil
 }
 
+// exitCodeError makes the process exit with the code without printing anything.
+type exitCodeError struct {
+	code int
+}
+
+func (e *exitCodeError) Error() string {
+	return fmt.Sprintf("exit status %d", e.code)
+}
+
 func main() {
 	if err := run(); err != nil {
-		_, _ = fmt.Fprintf(os.Stderr, "%+v\n", err)
+		var exitErr *exitCodeError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.code)
+		}
+
+		_, _ = fmt.Fprintln(os.Stderr, errorMessage(err))
 		os.Exit(1)
 	}
 }
+
+// errorMessage explains the errors the user can act on.
+func errorMessage(err error) string {
+	switch {
+	case errors.Is(err, saml.ErrLoginAborted):
+		return fmt.Sprintf("%v before signing in. Run the command again to sign in.", err)
+	case errors.Is(err, saml.ErrLoginTimeout):
+		return fmt.Sprintf("%v. Set --login-timeout or login_timeout in the config file to wait longer.", err)
+	default:
+		return fmt.Sprintf("%+v", err)
+	}
+}
@@ -0,0 +1,371 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/walkersumida/aws-sso-google/auth"
+	"github.com/walkersumida/aws-sso-google/config"
+	"github.com/walkersumida/aws-sso-google/credential"
+	"github.com/walkersumida/aws-sso-google/lock"
+	"github.com/walkersumida/aws-sso-google/partition"
+	"github.com/walkersumida/aws-sso-google/path"
+	"github.com/walkersumida/aws-sso-google/saml"
+	"github.com/walkersumida/aws-sso-google/sts"
+)
+
+// globalOptions holds the values of the persistent flags.
+type globalOptions struct {
+	configFile string
+	awsProfile string
+	flags      config.Profile
+	saveRole   bool
+	timeout    time.Duration
+}
+
+// boolFlag is a bool flag which stays nil unless it is set,
+// so that --flag=false overrides true in the config file.
+type boolFlag struct {
+	p **bool
+}
+
+// boolVarP defines the bool flag stored in p.
+func boolVarP(fs *pflag.FlagSet, p **bool, name, shorthand, usage string) {
+	fs.VarPF(boolFlag{p: p}, name, shorthand, usage).NoOptDefVal = "true"
+}
+
+func (f boolFlag) String() string {
+	return strconv.FormatBool(config.Bool(*f.p))
+}
+
+func (f boolFlag) Set(s string) error {
+	v, err := strconv.ParseBool(s)
+	if err != nil {
+		return err
+	}
+	*f.p = &v
+
+	return nil
+}
+
+func (f boolFlag) Type() string {
+	return "bool"
+}
+
+// configPath returns the --config flag or the default config file.
+func (o *globalOptions) configPath() (string, error) {
+	if o.configFile != "" {
+		return o.configFile, nil
+	}
+
+	p, err := path.ConfigFile()
+	if err != nil {
+		return "", fmt.Errorf("could not get config file: %w", err)
+	}
+
+	return p, nil
+}
+
+// context returns the context of the command bounded by --timeout.
+func (o *globalOptions) context(cmd *cobra.Command) (context.Context, context.CancelFunc) {
+	if o.timeout > 0 {
+		return context.WithTimeout(cmd.Context(), o.timeout)
+	}
+
+	return context.WithCancel(cmd.Context())
+}
+
+func (o *globalOptions) loadConfig() (*config.Config, error) {
+	p, err := o.configPath()
+	if err != nil {
+		return nil, err
+	}
+
+	return config.Load(p)
+}
+
+// resolveProfile merges the config file values of the profile with the flags.
+// Flags take precedence over the config file.
+func (o *globalOptions) resolveProfile() (config.Profile, error) {
+	cfg, err := o.loadConfig()
+	if err != nil {
+		return config.Profile{}, err
+	}
+
+	return o.resolveNamedProfile(cfg, o.awsProfile), nil
+}
+
+// resolveNamedProfile is resolveProfile for the named profile of cfg.
+func (o *globalOptions) resolveNamedProfile(cfg *config.Config, name string) config.Profile {
+	p := cfg.Profile(name).Merge(o.flags)
+	if p.LockTimeout == 0 {
+		p.LockTimeout = defaultLockTimeout
+	}
+	if p.RefreshSkew == 0 {
+		p.RefreshSkew = defaultRefreshSkew
+	}
+
+	return p
+}
+
+// usedKinds returns the distinct kinds, e.g. of credential storage, used by
+// the defaults and the profiles of cfg with the flags applied, or only by
+// the profile set by --aws-profile.
+func (o *globalOptions) usedKinds(cfg *config.Config, kind func(config.Profile) string) []string {
+	names := []string{o.awsProfile}
+	if o.awsProfile == "" {
+		names = append(names, cfg.ProfileNames()...)
+	}
+
+	var kinds []string
+	for _, name := range names {
+		k := kind(o.resolveNamedProfile(cfg, name))
+		if !slices.Contains(kinds, k) {
+			kinds = append(kinds, k)
+		}
+	}
+
+	return kinds
+}
+
+// storedKinds returns the kinds of credential storage that may hold
+// credentials: the plaintext file always, and the encrypted file and the
+// keyring if they have credentials or the config uses them.
+func (o *globalOptions) storedKinds(cfg *config.Config) ([]string, error) {
+	used := o.usedKinds(cfg, credentialStorageKind)
+	kinds := []string{credential.StorageFile}
+
+	p, err := path.EncryptedCredentialsFile()
+	if err != nil {
+		return nil, err
+	}
+	exists, err := path.Exists(p)
+	if err != nil {
+		return nil, err
+	}
+	if exists || slices.Contains(used, credential.StorageEncryptedFile) {
+		kinds = append(kinds, credential.StorageEncryptedFile)
+	}
+
+	// A keyring that cannot be read, e.g. without a Secret Service,
+	// holds no credentials unless the config uses it.
+	profiles, _ := credential.NewKeyringStorage().Profiles()
+	if len(profiles) > 0 || slices.Contains(used, credential.StorageKeyring) {
+		kinds = append(kinds, credential.StorageKeyring)
+	}
+
+	return kinds, nil
+}
+
+// credentialStorageKind returns the credential storage of the profile.
+func credentialStorageKind(p config.Profile) string {
+	if p.CredentialStorage == "" {
+		return credential.StorageFile
+	}
+
+	return p.CredentialStorage
+}
+
+// requireOptions returns an error listing the options that are set
+// neither by flags nor by the config file.
+func (o *globalOptions) requireOptions(p config.Profile, names ...string) error {
+	values := map[string]string{
+		"aws-profile":  o.awsProfile,
+		"aws-role-arn": p.AwsRoleArn,
+		"idp-id":       p.IDPID,
+		"sp-id":        p.SpID,
+	}
+
+	var missing []string
+	for _, name := range names {
+		if values[name] == "" {
+			missing = append(missing, fmt.Sprintf("%q", name))
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("required option(s) %s not set by flags or config file", strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
+// newAuth returns the Auth of the profile and the credential it fills.
+func (o *globalOptions) newAuth(p config.Profile) (*auth.Auth, *credential.Credential, error) {
+	required := []string{"aws-profile", "idp-id", "sp-id"}
+	if !isInteractive() {
+		required = append(required, "aws-role-arn")
+	}
+	if err := o.requireOptions(p, required...); err != nil {
+		return nil, nil, err
+	}
+
+	storage, err := credential.NewStorage(p.CredentialStorage)
+	if err != nil {
+		return nil, nil, err
+	}
+	c := newCredential(p, o.awsProfile, storage)
+	part, err := resolvePartition(p)
+	if err != nil {
+		return nil, nil, err
+	}
+	s, err := newSAML(p, p.AwsRoleArn, part)
+	if err != nil {
+		return nil, nil, err
+	}
+	a := auth.New(c, s, newSTS(p, o.awsProfile, part))
+	if p.AwsRoleArn == "" {
+		a.SelectRole = newRoleSelector(o)
+	}
+
+	a.Locker, err = newLocker(p)
+	if err != nil {
+		return nil, nil, err
+	}
+	a.Chain, err = newChain(p, o.awsProfile, c.Storage)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return a, c, nil
+}
+
+// newCredential returns the credential of the named profile in storage.
+func newCredential(p config.Profile, awsProfile string, storage credential.Storage) *credential.Credential {
+	c := credential.New(awsProfile, storage)
+	c.RefreshSkew = p.RefreshSkew
+	c.MinValidity = p.MinValidity
+
+	return c
+}
+
+// newChain returns the role chain of the profile.
+// The credentials each hop is assumed with are cached in storage
+// under "<profile>#<n>".
+func newChain(p config.Profile, awsProfile string, storage credential.Storage) ([]auth.Hop, error) {
+	var chain []auth.Hop
+	for i, h := range p.RoleChain {
+		if h.RoleArn == "" {
+			return nil, fmt.Errorf("role arn of hop %d in role chain must be set", i+1)
+		}
+
+		src := credential.New(fmt.Sprintf("%s#%d", awsProfile, i), storage)
+		src.RefreshSkew = p.RefreshSkew
+		// The chain expires with its sources, so they need the same validity.
+		src.MinValidity = p.MinValidity
+
+		chain = append(chain, auth.Hop{
+			Role: sts.Hop{
+				RoleArn:     h.RoleArn,
+				ExternalID:  h.ExternalID,
+				SessionName: h.SessionName,
+			},
+			Source: src,
+		})
+	}
+
+	return chain, nil
+}
+
+func newLocker(p config.Profile) (*lock.FileLock, error) {
+	lockFile, err := path.LockFile()
+	if err != nil {
+		return nil, err
+	}
+
+	return lock.New(lockFile, p.LockTimeout), nil
+}
+
+// resolvePartition returns the partition set by the profile, or else the
+// partition of its role arn. The aws partition is used if neither is set.
+func resolvePartition(p config.Profile) (partition.Partition, error) {
+	switch {
+	case p.Partition != "":
+		return partition.Get(p.Partition)
+	case p.AwsRoleArn != "":
+		return partition.FromArn(p.AwsRoleArn)
+	default:
+		return partition.AWS, nil
+	}
+}
+
+// newSTS returns the STS assuming the role of the profile in the partition.
+func newSTS(p config.Profile, awsProfile string, part partition.Partition) *sts.STS {
+	s := sts.New(awsProfile, p.AwsRegion, p.AwsRoleArn, p.AwsSessionDuration)
+	s.DefaultRegion = part.DefaultRegion
+
+	return s
+}
+
+// newVerifier returns the verifier of the assertions of the profile,
+// or nil if neither the IdP certificate nor metadata is set.
+// The metadata is only fetched when an assertion is verified.
+func newVerifier(p config.Profile, part partition.Partition) (*saml.Verifier, error) {
+	v := &saml.Verifier{
+		Metadata:    p.IDPMetadata,
+		Issuer:      p.IDPEntityID,
+		Audience:    part.SAMLAudience,
+		Destination: part.SAMLSigninURL,
+	}
+
+	switch {
+	case p.IDPCertificate != "":
+		b, err := readIDPCertificate(p.IDPCertificate)
+		if err != nil {
+			return nil, err
+		}
+		v.Certificates, err = saml.ParseCertificates(b)
+		if err != nil {
+			return nil, err
+		}
+	case p.IDPMetadata == "":
+		return nil, nil
+	}
+
+	return v, nil
+}
+
+// readIDPCertificate returns the PEM of the certificate,
+// which is set either as PEM itself or as the path of a PEM file.
+func readIDPCertificate(certificate string) ([]byte, error) {
+	if strings.HasPrefix(strings.TrimSpace(certificate), "-----BEGIN") {
+		return []byte(certificate), nil
+	}
+
+	b, err := os.ReadFile(certificate)
+	if err != nil {
+		return nil, fmt.Errorf("could not read IdP certificate: %w", err)
+	}
+
+	return b, nil
+}
+
+// newSAML returns the SAML signing in for the role of the profile.
+func newSAML(p config.Profile, awsRoleArn string, part partition.Partition) (*saml.SAML, error) {
+	cache, err := saml.NewAssertionCache(p.AssertionCache)
+	if err != nil {
+		return nil, err
+	}
+
+	s := saml.New(awsRoleArn, p.IDPID, p.SpID, p.Username, config.Bool(p.Clean))
+	s.Cache = cache
+	s.SigninURL = part.SAMLSigninURL
+	s.Browser = p.Browser
+	s.BrowserPath = p.BrowserPath
+	s.CDPURL = p.BrowserCDPURL
+	s.Headless = config.Bool(p.Headless)
+	s.HeadlessTimeout = p.HeadlessTimeout
+	s.LoginTimeout = p.LoginTimeout
+	s.Verifier, err = newVerifier(p, part)
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
@@ -0,0 +1,70 @@
+package partition
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Partition holds the endpoints of an AWS partition.
+type Partition struct {
+	ID            string
+	SAMLSigninURL string
+	FederationURL string
+	ConsoleURL    string
+
+	// SAMLAudience is the audience of the SAML assertions AWS accepts.
+	SAMLAudience string
+
+	// DefaultRegion is the region of the STS endpoint when no region is set.
+	DefaultRegion string
+}
+
+var (
+	AWS = Partition{
+		ID:            "aws",
+		SAMLSigninURL: "https://signin.aws.amazon.com/saml",
+		FederationURL: "https://signin.aws.amazon.com/federation",
+		ConsoleURL:    "https://console.aws.amazon.com/",
+		SAMLAudience:  "urn:amazon:webservices",
+		DefaultRegion: "us-east-1",
+	}
+	China = Partition{
+		ID:            "aws-cn",
+		SAMLSigninURL: "https://signin.amazonaws.cn/saml",
+		FederationURL: "https://signin.amazonaws.cn/federation",
+		ConsoleURL:    "https://console.amazonaws.cn/",
+		SAMLAudience:  "urn:amazon:webservices:cn-north-1",
+		DefaultRegion: "cn-north-1",
+	}
+	GovCloud = Partition{
+		ID:            "aws-us-gov",
+		SAMLSigninURL: "https://signin.amazonaws-us-gov.com/saml",
+		FederationURL: "https://signin.amazonaws-us-gov.com/federation",
+		ConsoleURL:    "https://console.amazonaws-us-gov.com/",
+		SAMLAudience:  "urn:amazon:webservices:govcloud",
+		DefaultRegion: "us-gov-west-1",
+	}
+)
+
+var partitions = []Partition{AWS, China, GovCloud}
+
+// Get returns the partition of the id.
+func Get(id string) (Partition, error) {
+	for _, p := range partitions {
+		if p.ID == id {
+			return p, nil
+		}
+	}
+
+	return Partition{}, fmt.Errorf("unknown partition: %s", id)
+}
+
+// FromArn returns the partition of the arn.
+func FromArn(arn string) (Partition, error) {
+	parts := strings.SplitN(arn, ":", 3)
+	if len(parts) < 3 || parts[0] != "arn" {
+		return Partition{}, fmt.Errorf("invalid arn: %s", arn)
+	}
+
+	return Get(parts[1])
+}
@@ -0,0 +1,60 @@
+package partition_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/walkersumida/aws-sso-google/partition"
+)
+
+func TestFromArn(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		give    string
+		want    partition.Partition
+		wantErr bool
+	}{
+		"when partition is aws": {
+			give: "arn:aws:iam::123456789012:role/Admin",
+			want: partition.AWS,
+		},
+		"when partition is aws-cn": {
+			give: "arn:aws-cn:iam::123456789012:role/Admin",
+			want: partition.China,
+		},
+		"when partition is aws-us-gov": {
+			give: "arn:aws-us-gov:iam::123456789012:role/Admin",
+			want: partition.GovCloud,
+		},
+		"when partition is unknown": {
+			give:    "arn:aws-xx:iam::123456789012:role/Admin",
+			wantErr: true,
+		},
+		"when not an arn": {
+			give:    "role/Admin",
+			wantErr: true,
+		},
+	}
+	for name, tt := range tests {
+		tt, name := tt, name
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := partition.FromArn(tt.give)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("want error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("mismatch (-want +got): ¥n%s", diff)
+			}
+		})
+	}
+}
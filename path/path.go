@@ -5,7 +5,10 @@ import (
 	"os"
 )
 
-const AppName = "aws-sso-google"
+const (
+	AppName       = "aws-sso-google"
+	ConfigFileEnv = "AWS_SSO_GOOGLE_CONFIG"
+)
 
 func UserDataDirForApp() (string, error) {
 	p, err := os.UserConfigDir()
@@ -16,6 +19,36 @@ func UserDataDirForApp() (string, error) {
 	return fmt.Sprintf("%s/%s", p, AppName), nil
 }
 
+// UserDataDirForBrowser returns the profile directory of the browser.
+// Chromium uses UserDataDirForApp, which predates the other browsers.
+func UserDataDirForBrowser(browser string) (string, error) {
+	p, err := UserDataDirForApp()
+	if err != nil {
+		return "", err
+	}
+	if browser == "chromium" {
+		return p, nil
+	}
+
+	return fmt.Sprintf("%s-%s", p, browser), nil
+}
+
+// ConfigFile returns the path of the config file.
+// It is kept outside of UserDataDirForApp because that directory is the
+// browser profile and is removed by the --clean flag.
+func ConfigFile() (string, error) {
+	if p := os.Getenv(ConfigFileEnv); p != "" {
+		return p, nil
+	}
+
+	p, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s/%s.yaml", p, AppName), nil
+}
+
 func CreateCacheDirForApp() error {
 	p, err := CacheDirForApp()
 	if err != nil {
@@ -43,6 +76,45 @@ func CredentialsFile() (string, error) {
 	return fmt.Sprintf("%s/%s", p, "credentials"), nil
 }
 
+func EncryptedCredentialsFile() (string, error) {
+	p, err := CacheDirForApp()
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s/%s", p, "credentials.age"), nil
+}
+
+// AssertionCacheFile returns the path of the encrypted SAML assertion cache.
+func AssertionCacheFile() (string, error) {
+	p, err := CacheDirForApp()
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s/%s", p, "assertions.age"), nil
+}
+
+// AssertionCacheKeyFile returns the path of the key of the assertion cache.
+func AssertionCacheKeyFile() (string, error) {
+	p, err := CacheDirForApp()
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s/%s", p, "assertions.key"), nil
+}
+
+// LockFile returns the path of the lock file serializing logins across processes.
+func LockFile() (string, error) {
+	p, err := CacheDirForApp()
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s/%s", p, "lock"), nil
+}
+
 func Exists(path string) (bool, error) {
 	_, err := os.Stat(path)
 	if os.IsNotExist(err) {
@@ -0,0 +1,175 @@
+package picker
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// ErrAborted is returned when the user cancels the selection.
+var ErrAborted = errors.New("selection aborted")
+
+const maxVisibleItems = 10
+
+const (
+	keyCtrlC     = 3
+	keyCtrlN     = 14
+	keyCtrlP     = 16
+	keyCtrlU     = 21
+	keyBackspace = 8
+	keyEnter     = 13
+	keyEscape    = 27
+	keyDelete    = 127
+)
+
+// Pick asks the user to choose one of items on the terminal
+// and returns the index of the chosen item.
+// It reads keys from stdin and draws on stderr so that stdout stays clean.
+func Pick(prompt string, items []string) (int, error) {
+	fd := int(os.Stdin.Fd())
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return -1, fmt.Errorf("could not make terminal raw: %w", err)
+	}
+	defer func() { _ = term.Restore(fd, state) }()
+
+	return Run(os.Stdin, os.Stderr, prompt, items)
+}
+
+// Run is Pick without terminal handling.
+// in must deliver raw key presses.
+func Run(in io.Reader, out io.Writer, prompt string, items []string) (int, error) {
+	if len(items) == 0 {
+		return -1, errors.New("no items to pick from")
+	}
+
+	var query []rune
+	cursor := 0
+	matched := Filter(items, "")
+	lines := 0
+	buf := make([]byte, 64)
+	for {
+		lines = render(out, prompt, string(query), items, matched, cursor, lines)
+
+		n, err := in.Read(buf)
+		if err != nil {
+			erase(out, lines)
+			if errors.Is(err, io.EOF) {
+				return -1, ErrAborted
+			}
+			return -1, err
+		}
+
+		for i := 0; i < n; i++ {
+			b := buf[i]
+			switch {
+			case b == keyEscape && i+2 < n && buf[i+1] == '[':
+				switch buf[i+2] {
+				case 'A':
+					cursor--
+				case 'B':
+					cursor++
+				}
+				i += 2
+			case b == keyEscape, b == keyCtrlC:
+				erase(out, lines)
+				return -1, ErrAborted
+			case b == keyEnter || b == '\n':
+				if len(matched) == 0 {
+					continue
+				}
+				erase(out, lines)
+				return matched[cursor], nil
+			case b == keyCtrlP:
+				cursor--
+			case b == keyCtrlN:
+				cursor++
+			case b == keyBackspace || b == keyDelete:
+				if len(query) > 0 {
+					query = query[:len(query)-1]
+				}
+			case b == keyCtrlU:
+				query = nil
+			case b >= ' ':
+				query = append(query, rune(b))
+			}
+		}
+
+		matched = Filter(items, string(query))
+		if cursor >= len(matched) {
+			cursor = len(matched) - 1
+		}
+		if cursor < 0 {
+			cursor = 0
+		}
+	}
+}
+
+// Filter returns the indexes of items that contain the characters of query
+// in order, ignoring case.
+func Filter(items []string, query string) []int {
+	q := []rune(strings.ToLower(query))
+	var idx []int
+	for i, item := range items {
+		if fuzzyMatch([]rune(strings.ToLower(item)), q) {
+			idx = append(idx, i)
+		}
+	}
+
+	return idx
+}
+
+func fuzzyMatch(s, q []rune) bool {
+	j := 0
+	for _, r := range s {
+		if j == len(q) {
+			break
+		}
+		if r == q[j] {
+			j++
+		}
+	}
+
+	return j == len(q)
+}
+
+// render draws the prompt and the visible items, replacing the previous
+// drawing of prevLines lines, and returns the number of lines drawn.
+func render(out io.Writer, prompt, query string, items []string, matched []int, cursor, prevLines int) int {
+	erase(out, prevLines)
+
+	start := 0
+	if cursor >= maxVisibleItems {
+		start = cursor - maxVisibleItems + 1
+	}
+	end := start + maxVisibleItems
+	if end > len(matched) {
+		end = len(matched)
+	}
+
+	var sb strings.Builder
+	for i := start; i < end; i++ {
+		mark := "  "
+		if i == cursor {
+			mark = "> "
+		}
+		sb.WriteString(mark + items[matched[i]] + "\r\n")
+	}
+	sb.WriteString(fmt.Sprintf("  %d/%d\r\n", len(matched), len(items)))
+	sb.WriteString(prompt + query)
+	_, _ = io.WriteString(out, sb.String())
+
+	return end - start + 1
+}
+
+// erase erases the lines drawn by render.
+func erase(out io.Writer, lines int) {
+	if lines > 0 {
+		_, _ = fmt.Fprintf(out, "\x1b[%dA", lines)
+	}
+	_, _ = io.WriteString(out, "\r\x1b[J")
+}
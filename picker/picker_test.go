@@ -0,0 +1,120 @@
+package picker_test
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/walkersumida/aws-sso-google/picker"
+)
+
+var items = []string{
+	"prod (999999999999) arn:aws:iam::999999999999:role/Admin",
+	"prod (999999999999) arn:aws:iam::999999999999:role/ReadOnly",
+	"dev (111111111111) arn:aws:iam::111111111111:role/Admin",
+}
+
+func TestFilter(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		giveQuery string
+		want      []int
+	}{
+		"when query is empty": {
+			giveQuery: "",
+			want:      []int{0, 1, 2},
+		},
+		"when query is a substring": {
+			giveQuery: "readonly",
+			want:      []int{1},
+		},
+		"when query is a subsequence": {
+			giveQuery: "devadm",
+			want:      []int{2},
+		},
+		"when nothing matches": {
+			giveQuery: "staging",
+			want:      nil,
+		},
+	}
+	for name, tt := range tests {
+		tt, name := tt, name
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got := picker.Filter(items, tt.giveQuery)
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("mismatch (-want +got): ¥n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		giveKeys []string
+		want     int
+		wantErr  error
+	}{
+		"when enter is pressed": {
+			giveKeys: []string{"\r"},
+			want:     0,
+		},
+		"when cursor is moved down": {
+			giveKeys: []string{"\x1b[B", "\x1b[B", "\r"},
+			want:     2,
+		},
+		"when query is typed": {
+			giveKeys: []string{"readonly", "\r"},
+			want:     1,
+		},
+		"when query is edited": {
+			giveKeys: []string{"devx", "\x7f", "\r"},
+			want:     2,
+		},
+		"when escape is pressed": {
+			giveKeys: []string{"\x1b"},
+			want:     -1,
+			wantErr:  picker.ErrAborted,
+		},
+		"when input ends": {
+			giveKeys: []string{},
+			want:     -1,
+			wantErr:  picker.ErrAborted,
+		},
+	}
+	for name, tt := range tests {
+		tt, name := tt, name
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := picker.Run(&keyReader{keys: tt.giveKeys}, io.Discard, "> ", items)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("unexpected error: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("mismatch (-want +got): ¥n%s", diff)
+			}
+		})
+	}
+}
+
+// keyReader returns one key sequence per Read like a raw terminal.
+type keyReader struct {
+	keys []string
+}
+
+func (r *keyReader) Read(p []byte) (int, error) {
+	if len(r.keys) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(p, r.keys[0])
+	r.keys = r.keys[1:]
+
+	return n, nil
+}
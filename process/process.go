@@ -0,0 +1,58 @@
+package process
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+)
+
+// Run runs c, forwards the signals sent to this process to it and returns
+// its exit code. c must not be started.
+//
+// c runs in the process group of this process, so an interrupt from the
+// terminal reaches it directly. SIGINT is therefore caught but not
+// forwarded, as a command like terraform stops without cleaning up on
+// the second one. SIGTERM and SIGHUP are forwarded, as they are usually
+// sent to this process alone.
+func Run(c *exec.Cmd) (int, error) {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	defer signal.Stop(sigs)
+
+	if err := c.Start(); err != nil {
+		return 0, fmt.Errorf("could not start %s: %w", c.Path, err)
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		for {
+			select {
+			case sig := <-sigs:
+				if sig != os.Interrupt {
+					_ = c.Process.Signal(sig)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	err := c.Wait()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		code := exitErr.ExitCode()
+		if code < 0 {
+			code = 1
+		}
+		return code, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("could not wait for %s: %w", c.Path, err)
+	}
+
+	return 0, nil
+}
@@ -0,0 +1,87 @@
+package process_test
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/walkersumida/aws-sso-google/process"
+)
+
+// TestMain runs the test binary as the child command when
+// PROCESS_TEST_CHILD is set.
+func TestMain(m *testing.M) {
+	switch os.Getenv("PROCESS_TEST_CHILD") {
+	case "":
+		os.Exit(m.Run())
+	case "exit":
+		code, _ := strconv.Atoi(os.Getenv("PROCESS_TEST_CODE"))
+		os.Exit(code)
+	case "signal":
+		// Report every signal until SIGTERM.
+		sigs := make(chan os.Signal, 2)
+		signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+		fmt.Println("ready")
+		for sig := range sigs {
+			fmt.Println(sig)
+			if sig == syscall.SIGTERM {
+				os.Exit(3)
+			}
+		}
+	}
+}
+
+// newChild returns the command running TestMain as the child.
+func newChild(t *testing.T, env ...string) *exec.Cmd {
+	t.Helper()
+
+	c := exec.Command(os.Args[0])
+	c.Env = append(os.Environ(), env...)
+
+	return c
+}
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		giveCode string
+		want     int
+	}{
+		"when the command succeeds": {
+			giveCode: "0",
+			want:     0,
+		},
+		"when the command fails": {
+			giveCode: "7",
+			want:     7,
+		},
+	}
+	for name, tt := range tests {
+		tt, name := tt, name
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := process.Run(newChild(t, "PROCESS_TEST_CHILD=exit", "PROCESS_TEST_CODE="+tt.giveCode))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("mismatch (-want +got): ¥n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRunNotFound(t *testing.T) {
+	t.Parallel()
+
+	if _, err := process.Run(exec.Command("aws-sso-google-no-such-command")); err == nil {
+		t.Error("want error, got nil")
+	}
+}
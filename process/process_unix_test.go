@@ -0,0 +1,63 @@
+//go:build unix
+
+package process_test
+
+import (
+	"bufio"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/walkersumida/aws-sso-google/process"
+)
+
+// TestRunSignal is not parallel, as it signals the test process.
+func TestRunSignal(t *testing.T) {
+	// A pipe of c.StdoutPipe is closed by Wait before the output may be read.
+	stdout, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := newChild(t, "PROCESS_TEST_CHILD=signal")
+	c.Stdout = w
+
+	type result struct {
+		code int
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		code, err := process.Run(c)
+		_ = w.Close()
+		done <- result{code: code, err: err}
+	}()
+
+	scanner := bufio.NewScanner(stdout)
+	var got []string
+	for scanner.Scan() {
+		if scanner.Text() == "ready" {
+			// SIGINT reaches the child from the terminal by itself,
+			// so only SIGTERM must be forwarded.
+			if err := syscall.Kill(os.Getpid(), syscall.SIGINT); err != nil {
+				t.Fatal(err)
+			}
+			if err := syscall.Kill(os.Getpid(), syscall.SIGTERM); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		got = append(got, scanner.Text())
+	}
+
+	res := <-done
+	if res.err != nil {
+		t.Fatalf("unexpected error: %v", res.err)
+	}
+	if diff := cmp.Diff(3, res.code); diff != "" {
+		t.Errorf("mismatch (-want +got): ¥n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"terminated"}, got); diff != "" {
+		t.Errorf("mismatch (-want +got): ¥n%s", diff)
+	}
+}
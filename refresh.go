@@ -0,0 +1,157 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/walkersumida/aws-sso-google/auth"
+	"github.com/walkersumida/aws-sso-google/config"
+	"github.com/walkersumida/aws-sso-google/credential"
+	"github.com/walkersumida/aws-sso-google/partition"
+)
+
+func newRefreshCmd(opts *globalOptions) *cobra.Command {
+	var all, force bool
+	var group string
+	cmd := &cobra.Command{
+		Use:   "refresh",
+		Short: "Refresh the credentials of several profiles with one sign in",
+		Long: `Refresh the credentials of several profiles with one sign in.
+
+Global flags such as --aws-region and --aws-session-duration override the
+config file of every profile refreshed. --aws-role-arn cannot be used.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Each profile has its own role, so one role for all of them is a mistake.
+			if opts.flags.AwsRoleArn != "" {
+				return errors.New("--aws-role-arn cannot be used with refresh, set aws_role_arn of each profile instead")
+			}
+
+			cfg, err := opts.loadConfig()
+			if err != nil {
+				return err
+			}
+
+			names, err := refreshProfileNames(cfg, all, group)
+			if err != nil {
+				return err
+			}
+
+			var first config.Profile
+			var firstPart partition.Partition
+			var targets []auth.Target
+			var creds []*credential.Credential
+			// Profiles share the storage of a kind, so that the passphrase
+			// of the encrypted file is asked for only once.
+			storages := map[string]credential.Storage{}
+			for i, name := range names {
+				p := opts.resolveNamedProfile(cfg, name)
+				if p.AwsRoleArn == "" {
+					return fmt.Errorf("aws role arn of profile %s must be set", name)
+				}
+				part, err := resolvePartition(p)
+				if err != nil {
+					return err
+				}
+				if i == 0 {
+					first, firstPart = p, part
+					if first.IDPID == "" || first.SpID == "" {
+						return fmt.Errorf("idp id and sp id of profile %s must be set", name)
+					}
+				} else if p.IDPID != first.IDPID || p.SpID != first.SpID {
+					return fmt.Errorf("profiles %s and %s must use the same idp id and sp id", names[0], name)
+				} else if part.ID != firstPart.ID {
+					return fmt.Errorf("profiles %s and %s must be in the same partition", names[0], name)
+				}
+
+				kind := credentialStorageKind(p)
+				storage, ok := storages[kind]
+				if !ok {
+					storage, err = credential.NewStorage(kind)
+					if err != nil {
+						return err
+					}
+					storages[kind] = storage
+				}
+				c := newCredential(p, name, storage)
+				chain, err := newChain(p, name, c.Storage)
+				if err != nil {
+					return err
+				}
+				creds = append(creds, c)
+				targets = append(targets, auth.Target{
+					AwsProfile: name,
+					AwsRoleArn: p.AwsRoleArn,
+					Credential: c,
+					STS:        newSTS(p, name, part),
+					Chain:      chain,
+				})
+			}
+
+			s, err := newSAML(first, "", firstPart)
+			if err != nil {
+				return err
+			}
+
+			b := auth.NewBatch(s, targets)
+			b.Force = force
+			b.Locker, err = newLocker(first)
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := opts.context(cmd)
+			defer cancel()
+			errs, err := b.RefreshContext(ctx)
+			if err != nil {
+				return err
+			}
+
+			failed := 0
+			for i, t := range targets {
+				if errs[i] != nil {
+					failed++
+					_, _ = fmt.Fprintf(os.Stderr, "%s: %v\n", t.AwsProfile, errs[i])
+					continue
+				}
+				_, _ = fmt.Fprintf(os.Stderr, "%s: valid until %s\n", t.AwsProfile, creds[i].Expiration.Format(time.RFC3339))
+			}
+			if failed > 0 {
+				return fmt.Errorf("could not refresh %d of %d profiles", failed, len(targets))
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&all, "all", false, "Refresh every profile in the config file")
+	cmd.Flags().StringVar(&group, "group", "", "Refresh the profiles of the group in the config file")
+	cmd.Flags().BoolVar(&force, "force", false, "Refresh credentials that are not expired yet")
+
+	return cmd
+}
+
+// refreshProfileNames returns the profiles selected by --all or --group.
+func refreshProfileNames(cfg *config.Config, all bool, group string) ([]string, error) {
+	switch {
+	case all && group != "":
+		return nil, errors.New("--all and --group cannot be used together")
+	case all:
+		names := cfg.ProfileNames()
+		if len(names) == 0 {
+			return nil, errors.New("no profile in config file")
+		}
+		return names, nil
+	case group != "":
+		names, ok := cfg.Groups[group]
+		if !ok || len(names) == 0 {
+			return nil, fmt.Errorf("could not find group: %s", group)
+		}
+		return names, nil
+	default:
+		return nil, errors.New("either --all or --group must be set")
+	}
+}
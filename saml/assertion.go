@@ -0,0 +1,188 @@
+package saml
+
+import (
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Names of the attributes read by AWS.
+// ref: https://docs.aws.amazon.com/IAM/latest/UserGuide/id_roles_providers_create_saml_assertions.html
+const (
+	AttributeRole              = "https://aws.amazon.com/SAML/Attributes/Role"
+	AttributeRoleSessionName   = "https://aws.amazon.com/SAML/Attributes/RoleSessionName"
+	AttributeSessionDuration   = "https://aws.amazon.com/SAML/Attributes/SessionDuration"
+	AttributePrincipalTag      = "https://aws.amazon.com/SAML/Attributes/PrincipalTag:"
+	AttributeTransitiveTagKeys = "https://aws.amazon.com/SAML/Attributes/TransitiveTagKeys"
+	AttributeSourceIdentity    = "https://aws.amazon.com/SAML/Attributes/SourceIdentity"
+)
+
+// Assertion is the content of the SAML assertion.
+// Fields are zero if the assertion does not have them.
+type Assertion struct {
+	Destination string // of the Response
+	Issuer      string
+	NameID      string
+
+	// SubjectConfirmationData
+	Recipient           string
+	SubjectNotOnOrAfter time.Time
+
+	// Conditions
+	NotBefore    time.Time
+	NotOnOrAfter time.Time
+	Audiences    []string
+
+	// AuthnStatement
+	AuthnInstant         time.Time
+	SessionIndex         string
+	SessionNotOnOrAfter  time.Time
+	AuthnContextClassRef string
+
+	// AWS attributes
+	Roles             []Role
+	InvalidRoles      []string // values of the Role attribute skipped as invalid
+	RoleSessionName   string
+	SessionDuration   time.Duration
+	PrincipalTags     map[string]string
+	TransitiveTagKeys []string
+	SourceIdentity    string
+}
+
+type XMLSAMLResponse struct {
+	Destination string       `xml:"Destination,attr"`
+	Assertion   XMLAssertion `xml:"Assertion"`
+}
+
+type XMLAssertion struct {
+	Issuer  string `xml:"Issuer"`
+	Subject struct {
+		NameID              string `xml:"NameID"`
+		SubjectConfirmation []struct {
+			SubjectConfirmationData struct {
+				Recipient    string    `xml:"Recipient,attr"`
+				NotOnOrAfter time.Time `xml:"NotOnOrAfter,attr"`
+			} `xml:"SubjectConfirmationData"`
+		} `xml:"SubjectConfirmation"`
+	} `xml:"Subject"`
+	Conditions struct {
+		NotBefore           time.Time `xml:"NotBefore,attr"`
+		NotOnOrAfter        time.Time `xml:"NotOnOrAfter,attr"`
+		AudienceRestriction []struct {
+			Audience []string `xml:"Audience"`
+		} `xml:"AudienceRestriction"`
+	} `xml:"Conditions"`
+	AuthnStatement struct {
+		AuthnInstant        time.Time `xml:"AuthnInstant,attr"`
+		SessionIndex        string    `xml:"SessionIndex,attr"`
+		SessionNotOnOrAfter time.Time `xml:"SessionNotOnOrAfter,attr"`
+		AuthnContext        struct {
+			AuthnContextClassRef string `xml:"AuthnContextClassRef"`
+		} `xml:"AuthnContext"`
+	} `xml:"AuthnStatement"`
+	AttributeStatement struct {
+		Attribute []struct {
+			Name           string `xml:"Name,attr"`
+			AttributeValue []struct {
+				Type     string `xml:"type,attr"`
+				Xsd      string `xml:"xsd,attr"`
+				Xsi      string `xml:"xsi,attr"`
+				CharData string `xml:",chardata"`
+			} `xml:"AttributeValue"`
+		} `xml:"Attribute"`
+	} `xml:"AttributeStatement"`
+}
+
+// ParseAssertion parses the base64 encoded SAMLResponse.
+func ParseAssertion(samlResponse string) (*Assertion, error) {
+	xmlSAMLRes, err := decode(samlResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseAssertion(xmlSAMLRes)
+}
+
+func decode(samlResponse string) (XMLSAMLResponse, error) {
+	xmlSAMLRes := XMLSAMLResponse{}
+
+	decodedSAMLRes, err := base64.StdEncoding.DecodeString(samlResponse)
+	if err != nil {
+		return xmlSAMLRes, fmt.Errorf("could not decode SAMLResponse: %w", err)
+	}
+
+	if err := xml.Unmarshal(decodedSAMLRes, &xmlSAMLRes); err != nil {
+		return xmlSAMLRes, fmt.Errorf("could not unmarshal SAMLResponse: %w", err)
+	}
+
+	return xmlSAMLRes, nil
+}
+
+func parseAssertion(xmlSAMLRes XMLSAMLResponse) (*Assertion, error) {
+	x := xmlSAMLRes.Assertion
+	a := &Assertion{
+		Destination:          strings.TrimSpace(xmlSAMLRes.Destination),
+		Issuer:               strings.TrimSpace(x.Issuer),
+		NameID:               strings.TrimSpace(x.Subject.NameID),
+		NotBefore:            x.Conditions.NotBefore,
+		NotOnOrAfter:         x.Conditions.NotOnOrAfter,
+		AuthnInstant:         x.AuthnStatement.AuthnInstant,
+		SessionIndex:         x.AuthnStatement.SessionIndex,
+		SessionNotOnOrAfter:  x.AuthnStatement.SessionNotOnOrAfter,
+		AuthnContextClassRef: strings.TrimSpace(x.AuthnStatement.AuthnContext.AuthnContextClassRef),
+	}
+
+	if len(x.Subject.SubjectConfirmation) > 0 {
+		data := x.Subject.SubjectConfirmation[0].SubjectConfirmationData
+		a.Recipient = strings.TrimSpace(data.Recipient)
+		a.SubjectNotOnOrAfter = data.NotOnOrAfter
+	}
+
+	roles, invalidRoles, err := findRoles(xmlSAMLRes)
+	if err != nil {
+		return nil, err
+	}
+	a.Roles = roles
+	a.InvalidRoles = invalidRoles
+
+	for _, r := range x.Conditions.AudienceRestriction {
+		for _, audience := range r.Audience {
+			a.Audiences = append(a.Audiences, strings.TrimSpace(audience))
+		}
+	}
+
+	for _, attr := range x.AttributeStatement.Attribute {
+		var values []string
+		for _, v := range attr.AttributeValue {
+			values = append(values, strings.TrimSpace(v.CharData))
+		}
+		if len(values) == 0 {
+			continue
+		}
+
+		switch {
+		case attr.Name == AttributeRoleSessionName:
+			a.RoleSessionName = values[0]
+		case attr.Name == AttributeSessionDuration:
+			seconds, err := strconv.Atoi(values[0])
+			if err != nil || seconds <= 0 {
+				return nil, fmt.Errorf("invalid SessionDuration: %q", values[0])
+			}
+			a.SessionDuration = time.Duration(seconds) * time.Second
+		case strings.HasPrefix(attr.Name, AttributePrincipalTag):
+			if a.PrincipalTags == nil {
+				a.PrincipalTags = map[string]string{}
+			}
+			a.PrincipalTags[strings.TrimPrefix(attr.Name, AttributePrincipalTag)] = values[0]
+		case attr.Name == AttributeTransitiveTagKeys:
+			a.TransitiveTagKeys = values
+		case attr.Name == AttributeSourceIdentity:
+			a.SourceIdentity = values[0]
+		}
+	}
+
+	return a, nil
+}
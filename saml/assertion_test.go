@@ -0,0 +1,144 @@
+package saml_test
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/walkersumida/aws-sso-google/saml"
+)
+
+func TestParseAssertion(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		giveFile string
+		want     *saml.Assertion
+		wantErr  bool
+	}{
+		"when assertion has every field": {
+			giveFile: "full.xml",
+			want: &saml.Assertion{
+				Destination:          "https://signin.aws.amazon.com/saml",
+				Issuer:               "https://accounts.google.com/o/saml2?idpid=XXXXXXXXX",
+				NameID:               "user@example.com",
+				Recipient:            "https://signin.aws.amazon.com/saml",
+				SubjectNotOnOrAfter:  time.Date(2024, 1, 1, 0, 5, 0, 0, time.UTC),
+				NotBefore:            time.Date(2023, 12, 31, 23, 55, 0, 0, time.UTC),
+				NotOnOrAfter:         time.Date(2024, 1, 1, 0, 5, 0, 0, time.UTC),
+				Audiences:            []string{"urn:amazon:webservices"},
+				AuthnInstant:         time.Date(2023, 12, 31, 23, 59, 0, 0, time.UTC),
+				SessionIndex:         "_session",
+				AuthnContextClassRef: "urn:oasis:names:tc:SAML:2.0:ac:classes:unspecified",
+				Roles: []saml.Role{
+					{
+						RoleArn:      "arn:aws:iam::123456789012:role/role-a",
+						PrincipalArn: "arn:aws:iam::123456789012:saml-provider/provider",
+					},
+					{
+						RoleArn:      "arn:aws:iam::123456789012:role/role-b",
+						PrincipalArn: "arn:aws:iam::123456789012:saml-provider/provider",
+					},
+				},
+				RoleSessionName: "user@example.com",
+				SessionDuration: 8 * time.Hour,
+				PrincipalTags: map[string]string{
+					"Department": "Engineering",
+					"CostCenter": "1234",
+				},
+				TransitiveTagKeys: []string{"Department", "CostCenter"},
+				SourceIdentity:    "user@example.com",
+			},
+		},
+		"when assertion has only the required fields": {
+			giveFile: "minimal.xml",
+			want: &saml.Assertion{
+				Issuer: "https://accounts.google.com/o/saml2?idpid=XXXXXXXXX",
+				NameID: "user@example.com",
+				Roles: []saml.Role{
+					{
+						RoleArn:      "arn:aws:iam::123456789012:role/role-a",
+						PrincipalArn: "arn:aws:iam::123456789012:saml-provider/provider",
+					},
+				},
+			},
+		},
+		"when a Role attribute value is invalid": {
+			giveFile: "invalid_role.xml",
+			want: &saml.Assertion{
+				Issuer: "https://accounts.google.com/o/saml2?idpid=XXXXXXXXX",
+				NameID: "user@example.com",
+				Roles: []saml.Role{
+					{
+						RoleArn:      "arn:aws:iam::123456789012:role/role-a",
+						PrincipalArn: "arn:aws:iam::123456789012:saml-provider/provider",
+					},
+				},
+				InvalidRoles: []string{"arn:aws:iam::123456789012:role/role-b"},
+			},
+		},
+		"when no Role attribute value is valid": {
+			giveFile: "no_valid_role.xml",
+			wantErr:  true,
+		},
+		"when session duration is not a number": {
+			giveFile: "invalid_session_duration.xml",
+			wantErr:  true,
+		},
+	}
+	for name, tt := range tests {
+		tt, name := tt, name
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := saml.ParseAssertion(readFixture(t, tt.giveFile))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("want error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("mismatch (-want +got): ¥n%s", diff)
+			}
+		})
+	}
+}
+
+func TestParseAssertionInvalid(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]string{
+		"when not base64": "not base64!",
+		"when not XML":    base64.StdEncoding.EncodeToString([]byte("<Response>")),
+	}
+	for name, give := range tests {
+		give := give
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if _, err := saml.ParseAssertion(give); err == nil {
+				t.Error("want error, got nil")
+			}
+		})
+	}
+}
+
+// readFixture returns the base64 encoded SAMLResponse in testdata.
+func readFixture(t *testing.T, name string) string {
+	t.Helper()
+
+	b, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return base64.StdEncoding.EncodeToString(b)
+}
@@ -0,0 +1,134 @@
+package saml
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/playwright-community/playwright-go"
+)
+
+// Browsers to sign in with.
+// Chrome and Edge are the installed browsers, the others are downloaded by Playwright.
+const (
+	BrowserChromium = "chromium"
+	BrowserFirefox  = "firefox"
+	BrowserWebKit   = "webkit"
+	BrowserChrome   = "chrome"
+	BrowserEdge     = "msedge"
+)
+
+// Browsers are the browsers that can be used for signing in.
+var Browsers = []string{BrowserChromium, BrowserFirefox, BrowserWebKit, BrowserChrome, BrowserEdge}
+
+// IsSupportedBrowser reports whether the browser can be used for signing in.
+func IsSupportedBrowser(browser string) bool {
+	return slices.Contains(Browsers, browser)
+}
+
+func (s *SAML) browser() string {
+	if s.Browser != "" {
+		return s.Browser
+	}
+
+	return BrowserChromium
+}
+
+// usesSystemBrowser reports whether the browser is not downloaded by Playwright.
+func (s *SAML) usesSystemBrowser() bool {
+	switch {
+	case s.CDPURL != "", s.BrowserPath != "":
+		return true
+	default:
+		b := s.browser()
+		return b == BrowserChrome || b == BrowserEdge
+	}
+}
+
+// installOptions returns the options installing the Playwright driver and
+// only the browser to use, if it is not a system browser.
+func (s *SAML) installOptions() *playwright.RunOptions {
+	if s.usesSystemBrowser() {
+		return &playwright.RunOptions{SkipInstallBrowsers: true}
+	}
+
+	return &playwright.RunOptions{Browsers: []string{s.browser()}}
+}
+
+// openPage opens a page in the browser and returns it with the function
+// closing what was opened. A browser connected over CDP is left running.
+func (s *SAML) openPage(pw *playwright.Playwright, userDataDir string, headless bool) (playwright.Page, func() error, error) {
+	if s.CDPURL != "" {
+		return s.openCDPPage(pw)
+	}
+
+	var browserType playwright.BrowserType
+	opts := playwright.BrowserTypeLaunchPersistentContextOptions{
+		Headless: playwright.Bool(headless),
+	}
+	switch s.browser() {
+	case BrowserChromium:
+		browserType = pw.Chromium
+	case BrowserFirefox:
+		browserType = pw.Firefox
+	case BrowserWebKit:
+		browserType = pw.WebKit
+	case BrowserChrome, BrowserEdge:
+		browserType = pw.Chromium
+		opts.Channel = playwright.String(s.browser())
+	default:
+		return nil, nil, fmt.Errorf("unknown browser: %s", s.browser())
+	}
+	if s.BrowserPath != "" {
+		opts.ExecutablePath = playwright.String(s.BrowserPath)
+		opts.Channel = nil
+	}
+
+	browserCtx, err := browserType.LaunchPersistentContext(userDataDir, opts)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not launch browser: %w", err)
+	}
+
+	page, err := browserCtx.NewPage()
+	if err != nil {
+		_ = browserCtx.Close()
+		return nil, nil, fmt.Errorf("could not create page: %w", err)
+	}
+
+	return page, func() error { return browserCtx.Close() }, nil
+}
+
+func (s *SAML) openCDPPage(pw *playwright.Playwright) (playwright.Page, func() error, error) {
+	browser, err := pw.Chromium.ConnectOverCDP(s.CDPURL)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not connect to browser over CDP: %w", err)
+	}
+
+	// The default context has the session of the browser.
+	var browserCtx playwright.BrowserContext
+	if contexts := browser.Contexts(); len(contexts) > 0 {
+		browserCtx = contexts[0]
+	} else {
+		browserCtx, err = browser.NewContext()
+		if err != nil {
+			_ = browser.Close()
+			return nil, nil, fmt.Errorf("could not create browser context: %w", err)
+		}
+	}
+
+	page, err := browserCtx.NewPage()
+	if err != nil {
+		_ = browser.Close()
+		return nil, nil, fmt.Errorf("could not create page: %w", err)
+	}
+
+	// Closing a browser connected over CDP only disconnects from it.
+	closePage := func() error {
+		err := page.Close()
+		if cerr := browser.Close(); err == nil {
+			err = cerr
+		}
+		return err
+	}
+
+	return page, closePage, nil
+}
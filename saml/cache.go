@@ -0,0 +1,361 @@
+package saml
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"filippo.io/age"
+	"github.com/walkersumida/aws-sso-google/path"
+	"github.com/zalando/go-keyring"
+)
+
+const (
+	AssertionCacheFile    = "file"
+	AssertionCacheKeyring = "keyring"
+	AssertionCacheNone    = "none"
+)
+
+// assertionCacheSkew is the validity an assertion must have left to be
+// reused, so that it does not expire before STS receives it.
+const assertionCacheSkew = 30 * time.Second
+
+// AssertionCache keeps SAML assertions until they expire, encrypted with
+// a key in Key, so that other profiles assume roles without signing in.
+type AssertionCache struct {
+	Path string // path.AssertionCacheFile() if empty
+	Key  KeyStore
+	Now  func() time.Time // time.Now if nil
+}
+
+// KeyStore stores the key encrypting the assertion cache.
+type KeyStore interface {
+	// Load returns the key, or an empty string if there is none.
+	Load() (string, error)
+	Save(key string) error
+	Delete() error
+}
+
+type cachedAssertion struct {
+	SAMLResponse string    `json:"SAMLResponse"`
+	NotOnOrAfter time.Time `json:"NotOnOrAfter"`
+}
+
+// NewAssertionCache returns the cache with the key stored in the kind of
+// store. The cache is opt-in, so nil is returned if kind is empty or
+// AssertionCacheNone.
+func NewAssertionCache(kind string) (*AssertionCache, error) {
+	switch kind {
+	case AssertionCacheFile:
+		return &AssertionCache{Key: &FileKeyStore{}}, nil
+	case AssertionCacheKeyring:
+		return &AssertionCache{Key: &KeyringKeyStore{}}, nil
+	case "", AssertionCacheNone:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unknown assertion cache: %s", kind)
+	}
+}
+
+// Load returns the cached SAMLResponse of the key, or an empty string if
+// there is none valid. A cache that cannot be decrypted is ignored.
+func (c *AssertionCache) Load(key string) (string, error) {
+	entries, err := c.load()
+	if err != nil {
+		return "", err
+	}
+
+	e, ok := entries[key]
+	if !ok || !c.now().Add(assertionCacheSkew).Before(e.NotOnOrAfter) {
+		return "", nil
+	}
+
+	return e.SAMLResponse, nil
+}
+
+// Save caches the SAMLResponse of the key until notOnOrAfter
+// and drops the expired ones.
+func (c *AssertionCache) Save(key, samlResponse string, notOnOrAfter time.Time) error {
+	entries, err := c.load()
+	if err != nil {
+		return err
+	}
+
+	for k, e := range entries {
+		if !c.now().Before(e.NotOnOrAfter) {
+			delete(entries, k)
+		}
+	}
+	entries[key] = cachedAssertion{
+		SAMLResponse: samlResponse,
+		NotOnOrAfter: notOnOrAfter,
+	}
+
+	return c.save(entries)
+}
+
+// Delete drops the cached SAMLResponse of the key
+// and reports whether there was one.
+func (c *AssertionCache) Delete(key string) (bool, error) {
+	entries, err := c.load()
+	if err != nil {
+		return false, err
+	}
+	if _, ok := entries[key]; !ok {
+		return false, nil
+	}
+
+	delete(entries, key)
+
+	return true, c.save(entries)
+}
+
+// Clear removes the cache and its key, and reports whether there was a cache.
+func (c *AssertionCache) Clear() (bool, error) {
+	p, err := c.path()
+	if err != nil {
+		return false, err
+	}
+
+	err = os.Remove(p)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return false, fmt.Errorf("could not remove assertion cache: %w", err)
+	}
+	removed := err == nil
+
+	if err := c.Key.Delete(); err != nil {
+		return false, err
+	}
+
+	return removed, nil
+}
+
+func (c *AssertionCache) load() (map[string]cachedAssertion, error) {
+	entries := map[string]cachedAssertion{}
+
+	p, err := c.path()
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := os.ReadFile(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return entries, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read assertion cache: %w", err)
+	}
+
+	key, err := c.Key.Load()
+	if err != nil {
+		return nil, err
+	}
+	if key == "" {
+		return entries, nil
+	}
+
+	identity, err := age.ParseX25519Identity(key)
+	if err != nil {
+		return entries, nil
+	}
+
+	r, err := age.Decrypt(bytes.NewReader(b), identity)
+	if err != nil {
+		return entries, nil
+	}
+	plain, err := io.ReadAll(r)
+	if err != nil {
+		return entries, nil
+	}
+	if err := json.Unmarshal(plain, &entries); err != nil {
+		return map[string]cachedAssertion{}, nil
+	}
+
+	return entries, nil
+}
+
+func (c *AssertionCache) save(entries map[string]cachedAssertion) error {
+	p, err := c.path()
+	if err != nil {
+		return err
+	}
+
+	identity, err := c.identity()
+	if err != nil {
+		return err
+	}
+
+	plain, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	w, err := age.Encrypt(&buf, identity.Recipient())
+	if err != nil {
+		return fmt.Errorf("could not encrypt assertion cache: %w", err)
+	}
+	if _, err := w.Write(plain); err != nil {
+		return fmt.Errorf("could not encrypt assertion cache: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("could not encrypt assertion cache: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(p), 0700); err != nil {
+		return err
+	}
+
+	return os.WriteFile(p, buf.Bytes(), 0600)
+}
+
+// identity returns the stored key, generating it on first use.
+func (c *AssertionCache) identity() (*age.X25519Identity, error) {
+	key, err := c.Key.Load()
+	if err != nil {
+		return nil, err
+	}
+	if key != "" {
+		if identity, err := age.ParseX25519Identity(key); err == nil {
+			return identity, nil
+		}
+	}
+
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		return nil, fmt.Errorf("could not generate assertion cache key: %w", err)
+	}
+	if err := c.Key.Save(identity.String()); err != nil {
+		return nil, err
+	}
+
+	return identity, nil
+}
+
+func (c *AssertionCache) path() (string, error) {
+	if c.Path != "" {
+		return c.Path, nil
+	}
+
+	return path.AssertionCacheFile()
+}
+
+func (c *AssertionCache) now() time.Time {
+	if c.Now != nil {
+		return c.Now()
+	}
+
+	return time.Now()
+}
+
+// FileKeyStore stores the key in a file readable only by the user.
+type FileKeyStore struct {
+	Path string // path.AssertionCacheKeyFile() if empty
+}
+
+var _ KeyStore = &FileKeyStore{}
+
+func (s *FileKeyStore) Load() (string, error) {
+	p, err := s.path()
+	if err != nil {
+		return "", err
+	}
+
+	b, err := os.ReadFile(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("could not read assertion cache key: %w", err)
+	}
+
+	return strings.TrimSpace(string(b)), nil
+}
+
+func (s *FileKeyStore) Save(key string) error {
+	p, err := s.path()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(p), 0700); err != nil {
+		return err
+	}
+
+	return os.WriteFile(p, []byte(key+"\n"), 0600)
+}
+
+func (s *FileKeyStore) Delete() error {
+	p, err := s.path()
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(p); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("could not remove assertion cache key: %w", err)
+	}
+
+	return nil
+}
+
+func (s *FileKeyStore) path() (string, error) {
+	if s.Path != "" {
+		return s.Path, nil
+	}
+
+	return path.AssertionCacheKeyFile()
+}
+
+// KeyringKeyStore stores the key in the OS keyring.
+type KeyringKeyStore struct {
+	Service string // path.AppName if empty
+}
+
+var _ KeyStore = &KeyringKeyStore{}
+
+// keyringUser is the keyring entry of the key,
+// apart from the profile names used by credential.KeyringStorage.
+const keyringUser = "#assertion-cache-key"
+
+func (s *KeyringKeyStore) Load() (string, error) {
+	key, err := keyring.Get(s.service(), keyringUser)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("could not get assertion cache key from keyring: %w", err)
+	}
+
+	return key, nil
+}
+
+func (s *KeyringKeyStore) Save(key string) error {
+	if err := keyring.Set(s.service(), keyringUser, key); err != nil {
+		return fmt.Errorf("could not set assertion cache key to keyring: %w", err)
+	}
+
+	return nil
+}
+
+func (s *KeyringKeyStore) Delete() error {
+	err := keyring.Delete(s.service(), keyringUser)
+	if err != nil && !errors.Is(err, keyring.ErrNotFound) {
+		return fmt.Errorf("could not delete assertion cache key from keyring: %w", err)
+	}
+
+	return nil
+}
+
+func (s *KeyringKeyStore) service() string {
+	if s.Service != "" {
+		return s.Service
+	}
+
+	return path.AppName
+}
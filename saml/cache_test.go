@@ -0,0 +1,192 @@
+package saml_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/walkersumida/aws-sso-google/saml"
+	"github.com/zalando/go-keyring"
+)
+
+func TestAssertionCache(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := map[string]struct {
+		giveNotOnOrAfter time.Time
+		giveNow          time.Time
+		want             string
+	}{
+		"when assertion is valid": {
+			giveNotOnOrAfter: now.Add(5 * time.Minute),
+			giveNow:          now,
+			want:             "saml",
+		},
+		"when assertion expires soon": {
+			giveNotOnOrAfter: now.Add(10 * time.Second),
+			giveNow:          now,
+			want:             "",
+		},
+		"when assertion is expired": {
+			giveNotOnOrAfter: now.Add(5 * time.Minute),
+			giveNow:          now.Add(10 * time.Minute),
+			want:             "",
+		},
+	}
+	for name, tt := range tests {
+		tt, name := tt, name
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			c := &saml.AssertionCache{
+				Path: filepath.Join(dir, "assertions.age"),
+				Key:  &saml.FileKeyStore{Path: filepath.Join(dir, "assertions.key")},
+				Now:  func() time.Time { return now },
+			}
+			if err := c.Save("idp", "saml", tt.giveNotOnOrAfter); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			c.Now = func() time.Time { return tt.giveNow }
+			got, err := c.Load("idp")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("mismatch (-want +got): ¥n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAssertionCacheDelete(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	c := &saml.AssertionCache{
+		Path: filepath.Join(dir, "assertions.age"),
+		Key:  &saml.FileKeyStore{Path: filepath.Join(dir, "assertions.key")},
+	}
+	notOnOrAfter := time.Now().Add(5 * time.Minute)
+	if err := c.Save("idp-a", "saml-a", notOnOrAfter); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := c.Save("idp-b", "saml-b", notOnOrAfter); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	removed, err := c.Delete("idp-a")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !removed {
+		t.Error("want removed, got not removed")
+	}
+
+	var got []string
+	for _, key := range []string{"idp-a", "idp-b"} {
+		v, err := c.Load(key)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		got = append(got, v)
+	}
+	if diff := cmp.Diff([]string{"", "saml-b"}, got); diff != "" {
+		t.Errorf("mismatch (-want +got): ¥n%s", diff)
+	}
+}
+
+func TestAssertionCacheEncrypted(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	p := filepath.Join(dir, "assertions.age")
+	keyFile := filepath.Join(dir, "assertions.key")
+	c := &saml.AssertionCache{
+		Path: p,
+		Key:  &saml.FileKeyStore{Path: keyFile},
+	}
+	if err := c.Save("idp", "secret-assertion", time.Now().Add(5*time.Minute)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	b, err := os.ReadFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(b, []byte("secret-assertion")) {
+		t.Error("assertion is stored in plaintext")
+	}
+
+	// A cache encrypted with a lost key is ignored.
+	if err := os.Remove(keyFile); err != nil {
+		t.Fatal(err)
+	}
+	got, err := c.Load("idp")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := cmp.Diff("", got); diff != "" {
+		t.Errorf("mismatch (-want +got): ¥n%s", diff)
+	}
+}
+
+func TestAssertionCacheKeyring(t *testing.T) {
+	keyring.MockInit()
+
+	c := &saml.AssertionCache{
+		Path: filepath.Join(t.TempDir(), "assertions.age"),
+		Key:  &saml.KeyringKeyStore{},
+	}
+	if err := c.Save("idp", "saml", time.Now().Add(5*time.Minute)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got, err := c.Load("idp")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := cmp.Diff("saml", got); diff != "" {
+		t.Errorf("mismatch (-want +got): ¥n%s", diff)
+	}
+}
+
+func TestAssertionCacheClear(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	c := &saml.AssertionCache{
+		Path: filepath.Join(dir, "assertions.age"),
+		Key:  &saml.FileKeyStore{Path: filepath.Join(dir, "assertions.key")},
+	}
+	if err := c.Save("idp", "saml", time.Now().Add(5*time.Minute)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var got []bool
+	for i := 0; i < 2; i++ {
+		removed, err := c.Clear()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		got = append(got, removed)
+	}
+	if diff := cmp.Diff([]bool{true, false}, got); diff != "" {
+		t.Errorf("mismatch (-want +got): ¥n%s", diff)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(0, len(entries)); diff != "" {
+		t.Errorf("mismatch (-want +got): ¥n%s", diff)
+	}
+}
@@ -0,0 +1,180 @@
+package saml
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// Metadata is the content of the SAML metadata of the IdP.
+type Metadata struct {
+	EntityID string
+
+	// SSOURLs are the locations of the SingleSignOnService.
+	SSOURLs []string
+
+	// Certificates are the signing certificates of the IdP.
+	Certificates []*x509.Certificate
+}
+
+type xmlMetadata struct {
+	EntityID         string `xml:"entityID,attr"`
+	IDPSSODescriptor struct {
+		KeyDescriptor []struct {
+			Use     string `xml:"use,attr"`
+			KeyInfo struct {
+				X509Data struct {
+					X509Certificate []string `xml:"X509Certificate"`
+				} `xml:"X509Data"`
+			} `xml:"KeyInfo"`
+		} `xml:"KeyDescriptor"`
+		SingleSignOnService []struct {
+			Location string `xml:"Location,attr"`
+		} `xml:"SingleSignOnService"`
+	} `xml:"IDPSSODescriptor"`
+}
+
+// fetchMetadataTimeout limits the time to download the metadata.
+const fetchMetadataTimeout = 30 * time.Second
+
+// ParseMetadata parses the EntityDescriptor of the IdP.
+func ParseMetadata(b []byte) (*Metadata, error) {
+	x := xmlMetadata{}
+	if err := xml.Unmarshal(b, &x); err != nil {
+		return nil, fmt.Errorf("could not unmarshal metadata: %w", err)
+	}
+
+	m := &Metadata{EntityID: strings.TrimSpace(x.EntityID)}
+	for _, sso := range x.IDPSSODescriptor.SingleSignOnService {
+		m.SSOURLs = append(m.SSOURLs, strings.TrimSpace(sso.Location))
+	}
+	for _, kd := range x.IDPSSODescriptor.KeyDescriptor {
+		if kd.Use != "" && kd.Use != "signing" {
+			continue
+		}
+		for _, data := range kd.KeyInfo.X509Data.X509Certificate {
+			der, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(data), ""))
+			if err != nil {
+				return nil, fmt.Errorf("could not decode certificate in metadata: %w", err)
+			}
+			cert, err := x509.ParseCertificate(der)
+			if err != nil {
+				return nil, fmt.Errorf("could not parse certificate in metadata: %w", err)
+			}
+			m.Certificates = append(m.Certificates, cert)
+		}
+	}
+	if len(m.Certificates) == 0 {
+		return nil, errors.New("could not find signing certificate in metadata")
+	}
+
+	return m, nil
+}
+
+// IDPID returns the idpid of the Google SAML app in the SSO URL or the entity ID.
+func (m *Metadata) IDPID() (string, error) {
+	urls := append(append([]string{}, m.SSOURLs...), m.EntityID)
+	for _, u := range urls {
+		parsed, err := url.Parse(u)
+		if err != nil {
+			continue
+		}
+		if idpID := parsed.Query().Get("idpid"); idpID != "" {
+			return idpID, nil
+		}
+	}
+
+	return "", errors.New("could not find idpid in metadata")
+}
+
+// LoadMetadata reads the metadata from the file or the http(s) URL.
+func LoadMetadata(ctx context.Context, location string) (*Metadata, error) {
+	var b []byte
+	var err error
+	if strings.HasPrefix(location, "https://") || strings.HasPrefix(location, "http://") {
+		b, err = fetchMetadata(ctx, location)
+	} else {
+		b, err = os.ReadFile(location)
+		if err != nil {
+			err = fmt.Errorf("could not read metadata: %w", err)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseMetadata(b)
+}
+
+func fetchMetadata(ctx context.Context, metadataURL string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, fetchMetadataTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, metadataURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not create metadata request: %w", err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch metadata: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("could not fetch metadata: %s", resp.Status)
+	}
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("could not read metadata: %w", err)
+	}
+
+	return b, nil
+}
+
+// ParseCertificates parses the PEM encoded certificates.
+func ParseCertificates(b []byte) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, b = pem.Decode(b)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse certificate: %w", err)
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, errors.New("could not find PEM certificate")
+	}
+
+	return certs, nil
+}
+
+// EncodeCertificates returns the certificates PEM encoded.
+func EncodeCertificates(certs []*x509.Certificate) string {
+	var b strings.Builder
+	for _, cert := range certs {
+		_ = pem.Encode(&b, &pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+	}
+
+	return b.String()
+}
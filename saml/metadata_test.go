@@ -0,0 +1,176 @@
+package saml_test
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/walkersumida/aws-sso-google/saml"
+)
+
+const metadataTemplate = `<?xml version="1.0" encoding="UTF-8"?>
+<md:EntityDescriptor xmlns:md="urn:oasis:names:tc:SAML:2.0:metadata" entityID="https://accounts.google.com/o/saml2?idpid=XXXXXXXXX" validUntil="2029-01-01T00:00:00.000Z">
+  <md:IDPSSODescriptor WantAuthnRequestsSigned="false" protocolSupportEnumeration="urn:oasis:names:tc:SAML:2.0:protocol">
+    <md:KeyDescriptor use="%s">
+      <ds:KeyInfo xmlns:ds="http://www.w3.org/2000/09/xmldsig#">
+        <ds:X509Data>
+          <ds:X509Certificate>%s</ds:X509Certificate>
+        </ds:X509Data>
+      </ds:KeyInfo>
+    </md:KeyDescriptor>
+    <md:NameIDFormat>urn:oasis:names:tc:SAML:1.1:nameid-format:emailAddress</md:NameIDFormat>
+    <md:SingleSignOnService Binding="urn:oasis:names:tc:SAML:2.0:bindings:HTTP-Redirect" Location="https://accounts.google.com/o/saml2/idp?idpid=XXXXXXXXX"/>
+    <md:SingleSignOnService Binding="urn:oasis:names:tc:SAML:2.0:bindings:HTTP-POST" Location="https://accounts.google.com/o/saml2/idp?idpid=XXXXXXXXX"/>
+  </md:IDPSSODescriptor>
+</md:EntityDescriptor>`
+
+func TestParseMetadata(t *testing.T) {
+	t.Parallel()
+
+	_, cert := newTestCertificate(t)
+	certData := base64.StdEncoding.EncodeToString(cert.Raw)
+
+	tests := map[string]struct {
+		give    string
+		want    *saml.Metadata
+		wantErr bool
+	}{
+		"when key is for signing": {
+			give: fmt.Sprintf(metadataTemplate, "signing", certData),
+			want: &saml.Metadata{
+				EntityID: "https://accounts.google.com/o/saml2?idpid=XXXXXXXXX",
+				SSOURLs: []string{
+					"https://accounts.google.com/o/saml2/idp?idpid=XXXXXXXXX",
+					"https://accounts.google.com/o/saml2/idp?idpid=XXXXXXXXX",
+				},
+				Certificates: []*x509.Certificate{cert},
+			},
+		},
+		"when key is only for encryption": {
+			give:    fmt.Sprintf(metadataTemplate, "encryption", certData),
+			wantErr: true,
+		},
+		"when certificate is invalid": {
+			give:    fmt.Sprintf(metadataTemplate, "signing", "aW52YWxpZA=="),
+			wantErr: true,
+		},
+	}
+	for name, tt := range tests {
+		tt, name := tt, name
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := saml.ParseMetadata([]byte(tt.give))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("want error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("mismatch (-want +got): ¥n%s", diff)
+			}
+		})
+	}
+}
+
+func TestMetadataIDPID(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		give    saml.Metadata
+		want    string
+		wantErr bool
+	}{
+		"when SSO URL has idpid": {
+			give: saml.Metadata{SSOURLs: []string{"https://accounts.google.com/o/saml2/idp?idpid=C0abc"}},
+			want: "C0abc",
+		},
+		"when only entity ID has idpid": {
+			give: saml.Metadata{EntityID: "https://accounts.google.com/o/saml2?idpid=C0abc"},
+			want: "C0abc",
+		},
+		"when neither has idpid": {
+			give:    saml.Metadata{EntityID: "https://idp.example.com"},
+			wantErr: true,
+		},
+	}
+	for name, tt := range tests {
+		tt, name := tt, name
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := tt.give.IDPID()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("want error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("mismatch (-want +got): ¥n%s", diff)
+			}
+		})
+	}
+}
+
+func TestLoadMetadata(t *testing.T) {
+	t.Parallel()
+
+	_, cert := newTestCertificate(t)
+	metadata := fmt.Sprintf(metadataTemplate, "signing", base64.StdEncoding.EncodeToString(cert.Raw))
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(metadata))
+	}))
+	t.Cleanup(srv.Close)
+
+	file := filepath.Join(t.TempDir(), "metadata.xml")
+	if err := os.WriteFile(file, []byte(metadata), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, location := range []string{srv.URL, file} {
+		got, err := saml.LoadMetadata(context.Background(), location)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !got.Certificates[0].Equal(cert) {
+			t.Errorf("certificate of %s mismatch", location)
+		}
+	}
+}
+
+func TestParseCertificates(t *testing.T) {
+	t.Parallel()
+
+	_, cert := newTestCertificate(t)
+
+	got, err := saml.ParseCertificates(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := cmp.Diff([]*x509.Certificate{cert}, got); diff != "" {
+		t.Errorf("mismatch (-want +got): ¥n%s", diff)
+	}
+
+	if _, err := saml.ParseCertificates([]byte("not a certificate")); err == nil {
+		t.Error("want error, got nil")
+	}
+}
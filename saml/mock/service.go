@@ -4,6 +4,7 @@
 package mock
 
 import (
+	"context"
 	"github.com/walkersumida/aws-sso-google/saml"
 	"sync"
 )
@@ -18,9 +19,15 @@ var _ saml.SAMLer = &SAMLerMock{}
 //
 //		// make and configure a mocked saml.SAMLer
 //		mockedSAMLer := &SAMLerMock{
+//			ForgetAssertionFunc: func() error {
+//				panic("mock out the ForgetAssertion method")
+//			},
 //			SigninFunc: func() (*saml.Response, error) {
 //				panic("mock out the Signin method")
 //			},
+//			SigninContextFunc: func(ctx context.Context) (*saml.Response, error) {
+//				panic("mock out the SigninContext method")
+//			},
 //		}
 //
 //		// use mockedSAMLer in code that requires saml.SAMLer
@@ -28,16 +35,59 @@ var _ saml.SAMLer = &SAMLerMock{}
 //
 //	}
 type SAMLerMock struct {
+	// ForgetAssertionFunc mocks the ForgetAssertion method.
+	ForgetAssertionFunc func() error
+
 	// SigninFunc mocks the Signin method.
 	SigninFunc func() (*saml.Response, error)
 
+	// SigninContextFunc mocks the SigninContext method.
+	SigninContextFunc func(ctx context.Context) (*saml.Response, error)
+
 	// calls tracks calls to the methods.
 	calls struct {
+		// ForgetAssertion holds details about calls to the ForgetAssertion method.
+		ForgetAssertion []struct {
+		}
 		// Signin holds details about calls to the Signin method.
 		Signin []struct {
 		}
+		// SigninContext holds details about calls to the SigninContext method.
+		SigninContext []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+		}
+	}
+	lockForgetAssertion sync.RWMutex
+	lockSignin          sync.RWMutex
+	lockSigninContext   sync.RWMutex
+}
+
+// ForgetAssertion calls ForgetAssertionFunc.
+func (mock *SAMLerMock) ForgetAssertion() error {
+	if mock.ForgetAssertionFunc == nil {
+		panic("SAMLerMock.ForgetAssertionFunc: method is nil but SAMLer.ForgetAssertion was just called")
+	}
+	callInfo := struct {
+	}{}
+	mock.lockForgetAssertion.Lock()
+	mock.calls.ForgetAssertion = append(mock.calls.ForgetAssertion, callInfo)
+	mock.lockForgetAssertion.Unlock()
+	return mock.ForgetAssertionFunc()
+}
+
+// ForgetAssertionCalls gets all the calls that were made to ForgetAssertion.
+// Check the length with:
+//
+//	len(mockedSAMLer.ForgetAssertionCalls())
+func (mock *SAMLerMock) ForgetAssertionCalls() []struct {
+} {
+	var calls []struct {
 	}
-	lockSignin sync.RWMutex
+	mock.lockForgetAssertion.RLock()
+	calls = mock.calls.ForgetAssertion
+	mock.lockForgetAssertion.RUnlock()
+	return calls
 }
 
 // Signin calls SigninFunc.
@@ -66,3 +116,35 @@ func (mock *SAMLerMock) SigninCalls() []struct {
 	mock.lockSignin.RUnlock()
 	return calls
 }
+
+// SigninContext calls SigninContextFunc.
+func (mock *SAMLerMock) SigninContext(ctx context.Context) (*saml.Response, error) {
+	if mock.SigninContextFunc == nil {
+		panic("SAMLerMock.SigninContextFunc: method is nil but SAMLer.SigninContext was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+	}{
+		Ctx: ctx,
+	}
+	mock.lockSigninContext.Lock()
+	mock.calls.SigninContext = append(mock.calls.SigninContext, callInfo)
+	mock.lockSigninContext.Unlock()
+	return mock.SigninContextFunc(ctx)
+}
+
+// SigninContextCalls gets all the calls that were made to SigninContext.
+// Check the length with:
+//
+//	len(mockedSAMLer.SigninContextCalls())
+func (mock *SAMLerMock) SigninContextCalls() []struct {
+	Ctx context.Context
+} {
+	var calls []struct {
+		Ctx context.Context
+	}
+	mock.lockSigninContext.RLock()
+	calls = mock.calls.SigninContext
+	mock.lockSigninContext.RUnlock()
+	return calls
+}
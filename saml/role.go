@@ -0,0 +1,91 @@
+package saml
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// reIAMArn matches the arns of IAM roles and SAML providers
+// in the partitions AWS supports SAML federation for.
+var reIAMArn = regexp.MustCompile(`^arn:(aws|aws-cn|aws-us-gov):iam::[0-9]{12}:(role|saml-provider)/[\w+=,.@/-]+$`)
+
+// ParseRole parses a value of the Role attribute, a pair of role and
+// principal arns separated by a comma in either order.
+func ParseRole(value string) (Role, error) {
+	parts := strings.Split(value, ",")
+	if len(parts) != 2 {
+		return Role{}, fmt.Errorf("invalid Role attribute value %q: want a pair of arns", value)
+	}
+
+	var role Role
+	for _, arn := range parts {
+		arn = strings.TrimSpace(arn)
+		m := reIAMArn.FindStringSubmatch(arn)
+		if m == nil {
+			return Role{}, fmt.Errorf("invalid Role attribute value %q: invalid arn %q", value, arn)
+		}
+
+		switch m[2] {
+		case "role":
+			role.RoleArn = arn
+		case "saml-provider":
+			role.PrincipalArn = arn
+		}
+	}
+	if role.RoleArn == "" || role.PrincipalArn == "" {
+		return Role{}, fmt.Errorf("invalid Role attribute value %q: want a role arn and a saml-provider arn", value)
+	}
+	if partition(role.RoleArn) != partition(role.PrincipalArn) {
+		return Role{}, fmt.Errorf("invalid Role attribute value %q: arns of different partitions", value)
+	}
+
+	return role, nil
+}
+
+// PrincipalArn returns the principal arn paired with the role arn,
+// or an empty string if the assertion does not have the role.
+func (a *Assertion) PrincipalArn(roleArn string) string {
+	for _, r := range a.Roles {
+		if r.RoleArn == roleArn {
+			return r.PrincipalArn
+		}
+	}
+
+	return ""
+}
+
+// findRoles returns the valid pairs of the Role attribute and the invalid
+// values skipped. An error is returned only if no value is valid, so that
+// one bad entry does not prevent signing in to the other roles.
+func findRoles(xmlSAMLRes XMLSAMLResponse) ([]Role, []string, error) {
+	var roles []Role
+	var invalid []string
+	var firstErr error
+	for _, attr := range xmlSAMLRes.Assertion.AttributeStatement.Attribute {
+		if attr.Name != AttributeRole {
+			continue
+		}
+
+		for _, attrVal := range attr.AttributeValue {
+			role, err := ParseRole(attrVal.CharData)
+			if err != nil {
+				invalid = append(invalid, attrVal.CharData)
+				if firstErr == nil {
+					firstErr = err
+				}
+				continue
+			}
+			roles = append(roles, role)
+		}
+	}
+	if len(roles) == 0 && firstErr != nil {
+		return nil, nil, firstErr
+	}
+
+	return roles, invalid, nil
+}
+
+func partition(arn string) string {
+	return strings.Split(arn, ":")[1]
+}
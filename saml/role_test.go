@@ -0,0 +1,155 @@
+package saml_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/walkersumida/aws-sso-google/saml"
+)
+
+func TestParseRole(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		give    string
+		want    saml.Role
+		wantErr bool
+	}{
+		"when role arn comes first": {
+			give: "arn:aws:iam::123456789012:role/Admin,arn:aws:iam::123456789012:saml-provider/provider",
+			want: saml.Role{
+				RoleArn:      "arn:aws:iam::123456789012:role/Admin",
+				PrincipalArn: "arn:aws:iam::123456789012:saml-provider/provider",
+			},
+		},
+		"when principal arn comes first": {
+			give: "arn:aws:iam::123456789012:saml-provider/provider,arn:aws:iam::123456789012:role/Admin",
+			want: saml.Role{
+				RoleArn:      "arn:aws:iam::123456789012:role/Admin",
+				PrincipalArn: "arn:aws:iam::123456789012:saml-provider/provider",
+			},
+		},
+		"when arns are surrounded by spaces": {
+			give: " arn:aws:iam::123456789012:role/Admin ,\n arn:aws:iam::123456789012:saml-provider/provider\n",
+			want: saml.Role{
+				RoleArn:      "arn:aws:iam::123456789012:role/Admin",
+				PrincipalArn: "arn:aws:iam::123456789012:saml-provider/provider",
+			},
+		},
+		"when role has a path": {
+			give: "arn:aws:iam::123456789012:role/team/Admin,arn:aws:iam::123456789012:saml-provider/provider",
+			want: saml.Role{
+				RoleArn:      "arn:aws:iam::123456789012:role/team/Admin",
+				PrincipalArn: "arn:aws:iam::123456789012:saml-provider/provider",
+			},
+		},
+		"when partition is aws-cn": {
+			give: "arn:aws-cn:iam::123456789012:role/Admin,arn:aws-cn:iam::123456789012:saml-provider/provider",
+			want: saml.Role{
+				RoleArn:      "arn:aws-cn:iam::123456789012:role/Admin",
+				PrincipalArn: "arn:aws-cn:iam::123456789012:saml-provider/provider",
+			},
+		},
+		"when partition is aws-us-gov": {
+			give: "arn:aws-us-gov:iam::123456789012:saml-provider/provider,arn:aws-us-gov:iam::123456789012:role/Admin",
+			want: saml.Role{
+				RoleArn:      "arn:aws-us-gov:iam::123456789012:role/Admin",
+				PrincipalArn: "arn:aws-us-gov:iam::123456789012:saml-provider/provider",
+			},
+		},
+		"when partitions differ": {
+			give:    "arn:aws:iam::123456789012:role/Admin,arn:aws-cn:iam::123456789012:saml-provider/provider",
+			wantErr: true,
+		},
+		"when partition is unknown": {
+			give:    "arn:aws-xx:iam::123456789012:role/Admin,arn:aws-xx:iam::123456789012:saml-provider/provider",
+			wantErr: true,
+		},
+		"when there are two role arns": {
+			give:    "arn:aws:iam::123456789012:role/Admin,arn:aws:iam::123456789012:role/ReadOnly",
+			wantErr: true,
+		},
+		"when there is only one arn": {
+			give:    "arn:aws:iam::123456789012:role/Admin",
+			wantErr: true,
+		},
+		"when there are three arns": {
+			give:    "arn:aws:iam::123456789012:role/Admin,arn:aws:iam::123456789012:saml-provider/provider,arn:aws:iam::123456789012:role/ReadOnly",
+			wantErr: true,
+		},
+		"when account id is invalid": {
+			give:    "arn:aws:iam::1234:role/Admin,arn:aws:iam::1234:saml-provider/provider",
+			wantErr: true,
+		},
+		"when empty": {
+			give:    "",
+			wantErr: true,
+		},
+	}
+	for name, tt := range tests {
+		tt, name := tt, name
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := saml.ParseRole(tt.give)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("want error, got %+v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("mismatch (-want +got): ¥n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAssertionPrincipalArn(t *testing.T) {
+	t.Parallel()
+
+	a := &saml.Assertion{
+		Roles: []saml.Role{
+			{
+				RoleArn:      "arn:aws:iam::123456789012:role/AdminReadOnly",
+				PrincipalArn: "arn:aws:iam::123456789012:saml-provider/readonly",
+			},
+			{
+				RoleArn:      "arn:aws:iam::123456789012:role/Admin",
+				PrincipalArn: "arn:aws:iam::123456789012:saml-provider/admin",
+			},
+		},
+	}
+
+	tests := map[string]struct {
+		give string
+		want string
+	}{
+		"when role matches exactly": {
+			give: "arn:aws:iam::123456789012:role/Admin",
+			want: "arn:aws:iam::123456789012:saml-provider/admin",
+		},
+		"when role is a prefix of another role": {
+			give: "arn:aws:iam::123456789012:role/AdminRead",
+			want: "",
+		},
+		"when role is not in the assertion": {
+			give: "arn:aws:iam::999999999999:role/Admin",
+			want: "",
+		},
+	}
+	for name, tt := range tests {
+		tt, name := tt, name
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if diff := cmp.Diff(tt.want, a.PrincipalArn(tt.give)); diff != "" {
+				t.Errorf("mismatch (-want +got): ¥n%s", diff)
+			}
+		})
+	}
+}
@@ -1,58 +1,99 @@
 package saml
 
 import (
-	"encoding/base64"
-	"encoding/xml"
+	"context"
+	"errors"
 	"fmt"
+	"net/http"
 	"net/url"
 	"os"
-	"regexp"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/playwright-community/playwright-go"
 	"github.com/walkersumida/aws-sso-google/path"
 )
 
 type SAMLer interface {
+	// ForgetAssertion drops the cached assertion, e.g. after STS rejected
+	// it, so that the next signin opens the browser.
+	ForgetAssertion() error
 	Signin() (*Response, error)
+	SigninContext(ctx context.Context) (*Response, error)
 }
 
 type SAML struct {
-	AwsRoleArn string // required
+	AwsRoleArn string // if empty, the role is not validated and only Roles is returned
 	Clean      bool
 	IDPID      string // required
 	SpID       string // required
 	Username   string
+
+	// Headless tries to sign in without a window using the saved browser
+	// session first, and opens a window as soon as Google asks for
+	// interaction. HeadlessTimeout bounds the headless sign in otherwise.
+	Headless        bool
+	HeadlessTimeout time.Duration // DefaultHeadlessTimeout if zero
+
+	// LoginTimeout limits the time the user has to sign in in the window.
+	LoginTimeout time.Duration // DefaultLoginTimeout if zero
+
+	// SigninURL is the AWS SAML endpoint of the partition the assertion is posted to.
+	SigninURL string // AwsSAMLSigninURL if empty
+
+	Browser     string // BrowserChromium if empty
+	BrowserPath string // executable of the browser, overriding Browser's
+	CDPURL      string // endpoint of a running browser to sign in with instead of launching one
+
+	// Cache reuses the assertion until it expires. It is optional.
+	Cache *AssertionCache
+
+	// Verifier rejects the assertion unless it is signed by the IdP and
+	// issued for AWS. If nil, the assertion is left to STS to verify.
+	Verifier *Verifier
 }
 
 var _ SAMLer = &SAML{}
 
 type Response struct {
+	RoleArn      string // AwsRoleArn if it is in the assertion
 	PrincipalArn string
+	Roles        []Role
 	SAMLResponse string
+
+	// Assertion is the parsed SAMLResponse.
+	Assertion *Assertion
+
+	// Cached is true if the SAMLResponse was read from the cache.
+	Cached bool
 }
 
-type XMLSAMLResponse struct {
-	Assertion struct {
-		AttributeStatement struct {
-			Attribute []struct {
-				Name           string `xml:"Name,attr"`
-				AttributeValue []struct {
-					Type     string `xml:"type,attr"`
-					Xsd      string `xml:"xsd,attr"`
-					Xsi      string `xml:"xsi,attr"`
-					CharData string `xml:",chardata"`
-				} `xml:"AttributeValue"`
-			} `xml:"Attribute"`
-		} `xml:"AttributeStatement"`
-	} `xml:"Assertion"`
+// Role is a pair of role and principal (SAML provider) arns
+// listed in the SAML assertion.
+type Role struct {
+	RoleArn      string `json:"RoleArn"`
+	PrincipalArn string `json:"PrincipalArn"`
 }
 
 const (
 	AwsSAMLSigninURL = "https://signin.aws.amazon.com/saml"
 	GoogleAccountURL = "https://accounts.google.com"
 
-	RegexpPrincipalArn = `(arn:aws:iam:[^:]*:[0-9]+:saml-provider\/[0-9a-zA-Z-_.]+)`
+	DefaultHeadlessTimeout = 30 * time.Second
+	DefaultLoginTimeout    = 5 * time.Minute
+)
+
+var (
+	// ErrLoginAborted is returned when the browser is closed before signing in.
+	ErrLoginAborted = errors.New("login aborted: the browser was closed")
+
+	// ErrLoginTimeout is returned when the user does not sign in within LoginTimeout.
+	ErrLoginTimeout = errors.New("login timed out")
+
+	// errInteractionRequired is returned when the headless browser
+	// could not sign in without the user.
+	errInteractionRequired = errors.New("interaction required")
 )
 
 func New(awsRoleArn, idpID, spID, username string, clean bool) *SAML {
@@ -66,71 +107,214 @@ func New(awsRoleArn, idpID, spID, username string, clean bool) *SAML {
 }
 
 func (s *SAML) Signin() (*Response, error) {
-	err := playwright.Install()
+	return s.SigninContext(context.Background())
+}
+
+// SigninContext is Signin that closes the browser and returns
+// the error of ctx when ctx is done.
+func (s *SAML) SigninContext(ctx context.Context) (*Response, error) {
+	if s.Cache != nil && !s.Clean {
+		cached, err := s.Cache.Load(s.CacheKey())
+		if err != nil {
+			return nil, err
+		}
+		if cached != "" {
+			// An assertion without the role falls back to signing in.
+			if res, err := s.newResponse(ctx, cached); err == nil {
+				res.Cached = true
+				return res, nil
+			}
+		}
+	}
+
+	samlResponse, err := s.browserSignin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := s.newResponse(ctx, samlResponse)
 	if err != nil {
-		return nil, fmt.Errorf("could not install playwright: %w", err)
+		return nil, err
+	}
+
+	// The cache only saves signing in again, so failing to write it is not an error.
+	// Clean leaves nothing behind, so the assertion is not cached either.
+	if s.Cache != nil && !s.Clean && !res.Assertion.NotOnOrAfter.IsZero() {
+		_ = s.Cache.Save(s.CacheKey(), samlResponse, res.Assertion.NotOnOrAfter)
+	}
+
+	return res, nil
+}
+
+func (s *SAML) ForgetAssertion() error {
+	if s.Cache == nil {
+		return nil
+	}
+
+	_, err := s.Cache.Delete(s.CacheKey())
+	return err
+}
+
+// browserSignin signs in with the browser and returns the SAMLResponse.
+func (s *SAML) browserSignin(ctx context.Context) (string, error) {
+	if !IsSupportedBrowser(s.browser()) {
+		return "", fmt.Errorf("unknown browser: %s", s.browser())
+	}
+
+	err := playwright.Install(s.installOptions())
+	if err != nil {
+		return "", fmt.Errorf("could not install playwright: %w", err)
 	}
 
 	pw, err := playwright.Run()
 	if err != nil {
-		return nil, fmt.Errorf("could not start playwright: %w", err)
+		return "", fmt.Errorf("could not start playwright: %w", err)
 	}
+	defer func() { _ = pw.Stop() }()
 
-	userDataDir, err := path.UserDataDirForApp()
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	userDataDir, err := path.UserDataDirForBrowser(s.browser())
 	if err != nil {
-		return nil, fmt.Errorf("could not get user data dir: %w", err)
+		return "", fmt.Errorf("could not get user data dir: %w", err)
 	}
 
 	if s.Clean {
 		if err := os.RemoveAll(userDataDir); err != nil {
-			return nil, fmt.Errorf("could not remove user data dir: %w", err)
+			return "", fmt.Errorf("could not remove user data dir: %w", err)
 		}
 	}
 
-	context, err := pw.Chromium.LaunchPersistentContext(
-		userDataDir,
-		playwright.BrowserTypeLaunchPersistentContextOptions{
-			Headless: playwright.Bool(false),
-		},
-	)
+	var samlResponse string
+	if s.Headless && !s.Clean && s.CDPURL == "" {
+		samlResponse, err = s.signin(ctx, pw, userDataDir, true)
+		if err != nil && !errors.Is(err, errInteractionRequired) {
+			return "", err
+		}
+	}
+	if samlResponse == "" {
+		samlResponse, err = s.signin(ctx, pw, userDataDir, false)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if s.Clean {
+		if err := os.RemoveAll(userDataDir); err != nil {
+			return "", fmt.Errorf("could not remove user data dir: %w", err)
+		}
+	}
+
+	return samlResponse, nil
+}
+
+// newResponse parses the SAMLResponse and finds the principal of the role.
+func (s *SAML) newResponse(ctx context.Context, samlResponse string) (*Response, error) {
+	var assertion *Assertion
+	var err error
+	if s.Verifier != nil {
+		assertion, err = s.Verifier.VerifyContext(ctx, samlResponse)
+	} else {
+		assertion, err = ParseAssertion(samlResponse)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("could not launch browser: %w", err)
+		return nil, err
+	}
+
+	res := &Response{
+		Roles:        assertion.Roles,
+		SAMLResponse: samlResponse,
+		Assertion:    assertion,
+	}
+	if s.AwsRoleArn == "" {
+		return res, nil
+	}
+
+	res.PrincipalArn = assertion.PrincipalArn(s.AwsRoleArn)
+	if res.PrincipalArn == "" {
+		if len(assertion.InvalidRoles) > 0 {
+			return nil, fmt.Errorf("could not find arn in SAML assertion: %s (skipped invalid Role attribute values %q)", s.AwsRoleArn, assertion.InvalidRoles)
+		}
+		return nil, fmt.Errorf("could not find arn in SAML assertion: %s", s.AwsRoleArn)
 	}
+	res.RoleArn = s.AwsRoleArn
+
+	return res, nil
+}
 
-	page, err := context.NewPage()
+// CacheKey identifies the SAML app the assertions are issued for in Cache.
+func (s *SAML) CacheKey() string {
+	return strings.Join([]string{s.IDPID, s.SpID, s.signinURL()}, " ")
+}
+
+// signin opens the SAML URL in the browser and returns the SAMLResponse
+// posted to AWS. The post is captured and aborted, so the AWS sign-in page
+// is never loaded. In headless mode, errInteractionRequired is returned as
+// soon as Google asks for interaction, or if the saved browser session does
+// not reach AWS within HeadlessTimeout.
+func (s *SAML) signin(ctx context.Context, pw *playwright.Playwright, userDataDir string, headless bool) (samlResponse string, err error) {
+	page, closePage, err := s.openPage(pw, userDataDir, headless)
 	if err != nil {
-		return nil, fmt.Errorf("could not create page: %w", err)
+		return "", err
 	}
+	defer func() { _ = closePage() }()
+
+	// Closing the browser makes the pending playwright calls fail,
+	// which are then reported as the error of ctx or, if the user closed
+	// it, as ErrLoginAborted.
+	closed := make(chan struct{})
+	var closeOnce sync.Once
+	page.OnClose(func(playwright.Page) { closeOnce.Do(func() { close(closed) }) })
+	stop := context.AfterFunc(ctx, func() { _ = closePage() })
+	defer stop()
+	defer func() {
+		switch {
+		case ctx.Err() != nil:
+			samlResponse, err = "", ctx.Err()
+		case err != nil && isClosed(closed):
+			samlResponse, err = "", ErrLoginAborted
+		}
+	}()
 
 	page.SetDefaultTimeout(0)
 	page.SetDefaultNavigationTimeout(0)
 
-	var samlResponse string
-	var errInRoute error
-	err = page.Route("**/*", func(route playwright.Route) {
-		err = route.Continue()
-		if err != nil {
-			errInRoute = fmt.Errorf("could not continue: %w", err)
+	type post struct {
+		samlResponse string
+		err          error
+	}
+	posted := make(chan post, 1)
+	err = page.Route(s.signinURL(), func(route playwright.Route) {
+		if route.Request().Method() != http.MethodPost {
+			_ = route.Continue()
 			return
 		}
 
-		if route.Request().URL() == AwsSAMLSigninURL {
-			req, err := route.Request().PostData()
-			if err != nil {
-				errInRoute = fmt.Errorf("could not get postData: %w", err)
-				return
-			}
+		var p post
+		p.samlResponse, p.err = readSAMLResponse(route.Request())
+		_ = route.Abort()
 
-			resRemovedKey := strings.ReplaceAll(req, "SAMLResponse=", "")
-			samlResponse, err = url.QueryUnescape(resRemovedKey)
-			if err != nil {
-				errInRoute = fmt.Errorf("could not unescape: %w", err)
-				return
-			}
+		select {
+		case posted <- p:
+		default:
 		}
 	})
 	if err != nil {
-		return nil, fmt.Errorf("could not route: %w", err)
+		return "", fmt.Errorf("could not route: %w", err)
+	}
+
+	// Google redirecting to the account chooser, the sign in or a challenge
+	// means that the saved session cannot sign in without the user.
+	interaction := make(chan struct{})
+	if headless {
+		var interactionOnce sync.Once
+		page.OnFrameNavigated(func(frame playwright.Frame) {
+			if frame.ParentFrame() == nil && IsInteractionURL(frame.URL()) {
+				interactionOnce.Do(func() { close(interaction) })
+			}
+		})
 	}
 
 	_, err = page.Goto(
@@ -140,135 +324,132 @@ func (s *SAML) Signin() (*Response, error) {
 		},
 	)
 	if err != nil {
-		return nil, fmt.Errorf("could not goto: %w", err)
+		return "", fmt.Errorf("could not goto: %w", err)
+	}
+
+	if headless {
+		cnt, err := page.Locator(`input[type="email"], input[type="password"]`).Count()
+		if err != nil {
+			return "", fmt.Errorf("could not count: %w", err)
+		}
+		if cnt > 0 {
+			return "", errInteractionRequired
+		}
 	}
 
-	if s.Username != "" {
+	if s.Username != "" && !headless {
 		cnt, err := page.Locator("input[type=\"email\"]").Count()
 		if err != nil {
-			return nil, fmt.Errorf("could not count: %w", err)
+			return "", fmt.Errorf("could not count: %w", err)
 		}
 
 		if cnt > 0 {
 			if err := page.Locator("input[type=\"email\"]").First().Fill(s.Username); err != nil {
-				return nil, fmt.Errorf("could not fill username: %w", err)
+				return "", fmt.Errorf("could not fill username: %w", err)
 			}
 		}
 	}
 
-	err = page.WaitForURL(AwsSAMLSigninURL, playwright.PageWaitForURLOptions{
-		WaitUntil: playwright.WaitUntilStateLoad,
-	})
-	if err != nil {
-		return nil, fmt.Errorf("could not wait for URL: %w", err)
+	timeout := s.loginTimeout()
+	if headless {
+		timeout = s.headlessTimeout()
 	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
 
-	err = page.WaitForLoadState(playwright.PageWaitForLoadStateOptions{
-		State: playwright.LoadStateLoad,
-	})
-	if err != nil {
-		return nil, fmt.Errorf("could not wait for load state: %w", err)
-	}
-	if errInRoute != nil {
-		return nil, fmt.Errorf("could not route: %w", errInRoute)
+	select {
+	case p := <-posted:
+		if p.err != nil {
+			return "", p.err
+		}
+		samlResponse = p.samlResponse
+	case <-interaction:
+		return "", errInteractionRequired
+	case <-timer.C:
+		if headless {
+			return "", errInteractionRequired
+		}
+		return "", fmt.Errorf("%w after %s", ErrLoginTimeout, timeout)
+	case <-closed:
+		return "", ErrLoginAborted
+	case <-ctx.Done():
+		return "", ctx.Err()
 	}
 
-	arns, err := findRoleArns(page)
-	if err != nil {
-		return nil, fmt.Errorf("could not find arns: %w", err)
-	}
-	if !validateArn(arns, s.AwsRoleArn) {
-		return nil, fmt.Errorf("could not find arn: %s", s.AwsRoleArn)
+	if err := closePage(); err != nil {
+		return "", fmt.Errorf("could not close browser: %w", err)
 	}
 
-	if err := stopPlaywright(pw, context); err != nil {
-		return nil, fmt.Errorf("could not stop Playwright: %w", err)
-	}
+	return samlResponse, nil
+}
 
-	if s.Clean {
-		if err := os.RemoveAll(userDataDir); err != nil {
-			return nil, fmt.Errorf("could not remove user data dir: %w", err)
-		}
+// IsInteractionURL reports whether the URL is a Google page the user has to
+// interact with, such as the account chooser, the sign in or a challenge.
+func IsInteractionURL(u string) bool {
+	parsed, err := url.Parse(u)
+	if err != nil || parsed.Host != "accounts.google.com" {
+		return false
 	}
 
-	decodedSAMLRes, err := base64.StdEncoding.DecodeString(samlResponse)
+	p := parsed.Path
+	return strings.Contains(p, "/signin/") ||
+		strings.HasPrefix(p, "/ServiceLogin") ||
+		strings.HasPrefix(p, "/AccountChooser")
+}
+
+// readSAMLResponse returns the SAMLResponse in the form posted by the request.
+func readSAMLResponse(req playwright.Request) (string, error) {
+	data, err := req.PostData()
 	if err != nil {
-		return nil, fmt.Errorf("could not decode SAMLResponse: %w", err)
+		return "", fmt.Errorf("could not get postData: %w", err)
 	}
 
-	xmlSAMLRes := XMLSAMLResponse{}
-	err = xml.Unmarshal(decodedSAMLRes, &xmlSAMLRes)
+	form, err := url.ParseQuery(data)
 	if err != nil {
-		return nil, fmt.Errorf("could not unmarshal SAMLResponse: %w", err)
+		return "", fmt.Errorf("could not parse postData: %w", err)
 	}
 
-	principalArn := findPrincipalArn(s.AwsRoleArn, xmlSAMLRes)
-	if principalArn == "" {
-		return nil, fmt.Errorf("could not find principalArn")
+	samlResponse := form.Get("SAMLResponse")
+	if samlResponse == "" {
+		return "", errors.New("could not find SAMLResponse in postData")
 	}
 
-	return &Response{
-		SAMLResponse: samlResponse,
-		PrincipalArn: principalArn,
-	}, nil
+	return samlResponse, nil
 }
 
-func (s *SAML) buildSamlURL() string {
-	return fmt.Sprintf("%s/o/saml2/initsso?idpid=%s&spid=%s&forceauthn=false", GoogleAccountURL, s.IDPID, s.SpID)
+func isClosed(closed <-chan struct{}) bool {
+	select {
+	case <-closed:
+		return true
+	default:
+		return false
+	}
 }
 
-func findPrincipalArn(roleArn string, xmlSAMLRes XMLSAMLResponse) string {
-	for _, attr := range xmlSAMLRes.Assertion.AttributeStatement.Attribute {
-		if attr.Name == "https://aws.amazon.com/SAML/Attributes/Role" {
-			for _, attrVal := range attr.AttributeValue {
-				if strings.Contains(attrVal.CharData, roleArn) {
-					re := regexp.MustCompile(RegexpPrincipalArn)
-					principalArn := re.FindString(attrVal.CharData)
-					return principalArn
-				}
-			}
-		}
+func (s *SAML) headlessTimeout() time.Duration {
+	if s.HeadlessTimeout > 0 {
+		return s.HeadlessTimeout
 	}
 
-	return ""
+	return DefaultHeadlessTimeout
 }
 
-func findRoleArns(page playwright.Page) ([]string, error) {
-	loc := page.Locator("label[for*=\"arn\"]")
-	arns, err := loc.All()
-	if err != nil {
-		return nil, fmt.Errorf("could not get arns: %w", err)
-	}
-
-	var arnValues []string
-	for _, arn := range arns {
-		value, err := arn.GetAttribute("for")
-		if err != nil {
-			return nil, fmt.Errorf("could not get text content: %w", err)
-		}
-		arnValues = append(arnValues, value)
+func (s *SAML) loginTimeout() time.Duration {
+	if s.LoginTimeout > 0 {
+		return s.LoginTimeout
 	}
 
-	return arnValues, nil
+	return DefaultLoginTimeout
 }
 
-func validateArn(allArns []string, arn string) bool {
-	for _, a := range allArns {
-		if a == arn {
-			return true
-		}
+func (s *SAML) signinURL() string {
+	if s.SigninURL != "" {
+		return s.SigninURL
 	}
 
-	return false
+	return AwsSAMLSigninURL
 }
 
-func stopPlaywright(pw *playwright.Playwright, browserCtx playwright.BrowserContext) error {
-	if err := browserCtx.Close(); err != nil {
-		return err
-	}
-	if err := pw.Stop(); err != nil {
-		return err
-	}
-
-	return nil
+func (s *SAML) buildSamlURL() string {
+	return fmt.Sprintf("%s/o/saml2/initsso?idpid=%s&spid=%s&forceauthn=false", GoogleAccountURL, s.IDPID, s.SpID)
 }
@@ -0,0 +1,56 @@
+package saml_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/walkersumida/aws-sso-google/saml"
+)
+
+func TestIsInteractionURL(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		give string
+		want bool
+	}{
+		"when it is the sign in": {
+			give: "https://accounts.google.com/v3/signin/identifier?continue=x",
+			want: true,
+		},
+		"when it is a challenge": {
+			give: "https://accounts.google.com/signin/challenge/pwd",
+			want: true,
+		},
+		"when it is the account chooser": {
+			give: "https://accounts.google.com/AccountChooser?continue=x",
+			want: true,
+		},
+		"when it is the old sign in": {
+			give: "https://accounts.google.com/ServiceLogin?continue=x",
+			want: true,
+		},
+		"when it is the SAML app": {
+			give: "https://accounts.google.com/o/saml2/initsso?idpid=idp&spid=sp",
+			want: false,
+		},
+		"when it is AWS": {
+			give: "https://signin.aws.amazon.com/saml",
+			want: false,
+		},
+		"when it is another host": {
+			give: "https://example.com/signin/challenge",
+			want: false,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if diff := cmp.Diff(tt.want, saml.IsInteractionURL(tt.give)); diff != "" {
+				t.Errorf("mismatch (-want +got): ¥n%s", diff)
+			}
+		})
+	}
+}
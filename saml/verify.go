@@ -0,0 +1,218 @@
+package saml
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/beevik/etree"
+	dsig "github.com/russellhaering/goxmldsig"
+)
+
+const (
+	// AwsSAMLAudience is the audience of the assertions for the aws partition.
+	AwsSAMLAudience = "urn:amazon:webservices"
+
+	// verifyClockSkew is the difference allowed between the clocks of the IdP and this host.
+	verifyClockSkew = time.Minute
+)
+
+var (
+	ErrInvalidSignature   = errors.New("invalid SAML signature")
+	ErrInvalidIssuer      = errors.New("SAML assertion is not issued by the IdP")
+	ErrInvalidAudience    = errors.New("SAML assertion is not for AWS")
+	ErrInvalidDestination = errors.New("SAML assertion is not for the AWS signin URL")
+	ErrAssertionExpired   = errors.New("SAML assertion expired")
+	ErrAssertionNotYet    = errors.New("SAML assertion is not yet valid")
+)
+
+// Verifier checks that a SAMLResponse is signed by the IdP and issued for AWS
+// before it is used.
+type Verifier struct {
+	// Certificates are the signing certificates of the IdP.
+	// If empty, they are loaded from Metadata on the first verification.
+	Certificates []*x509.Certificate
+	Metadata     string // file or http(s) URL of the IdP metadata
+
+	Issuer      string           // entity ID of the IdP, not checked if empty
+	Audience    string           // AwsSAMLAudience if empty
+	Destination string           // AwsSAMLSigninURL if empty
+	Now         func() time.Time // time.Now if nil
+}
+
+// Verify verifies the SAMLResponse and returns the signed assertion.
+// Only the signed elements are read, so content added around the
+// signature is ignored.
+func (v *Verifier) Verify(samlResponse string) (*Assertion, error) {
+	return v.VerifyContext(context.Background(), samlResponse)
+}
+
+// VerifyContext is Verify that loads the metadata within ctx.
+func (v *Verifier) VerifyContext(ctx context.Context, samlResponse string) (*Assertion, error) {
+	b, err := base64.StdEncoding.DecodeString(samlResponse)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode SAMLResponse: %w", err)
+	}
+
+	doc := etree.NewDocument()
+	if err := doc.ReadFromBytes(b); err != nil {
+		return nil, fmt.Errorf("could not parse SAMLResponse: %w", err)
+	}
+	res := doc.Root()
+	if res == nil || res.Tag != "Response" {
+		return nil, errors.New("could not find Response in SAMLResponse")
+	}
+
+	certs, err := v.certificates(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	vctx := dsig.NewDefaultValidationContext(&dsig.MemoryX509CertificateStore{Roots: certs})
+	vctx.Clock = dsig.NewFakeClockAt(v.now())
+
+	// Google signs either the whole response or only the assertion.
+	responseSigned := res.SelectElement("Signature") != nil
+	if responseSigned {
+		res, err = vctx.Validate(res)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidSignature, err)
+		}
+	}
+
+	if len(res.SelectElements("EncryptedAssertion")) > 0 {
+		return nil, errors.New("encrypted SAML assertions are not supported")
+	}
+	assertions := res.SelectElements("Assertion")
+	if len(assertions) != 1 {
+		return nil, fmt.Errorf("SAMLResponse must have exactly one assertion, got %d", len(assertions))
+	}
+	assertion := assertions[0]
+	if !responseSigned {
+		assertion, err = vctx.Validate(assertion)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidSignature, err)
+		}
+	}
+
+	xmlSAMLRes, err := unmarshalSigned(res, assertion)
+	if err != nil {
+		return nil, err
+	}
+	a, err := parseAssertion(xmlSAMLRes)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := v.verifyConditions(a); err != nil {
+		return nil, err
+	}
+
+	return a, nil
+}
+
+// unmarshalSigned reads the Destination of the response and the assertion.
+func unmarshalSigned(res, assertion *etree.Element) (XMLSAMLResponse, error) {
+	xmlSAMLRes := XMLSAMLResponse{
+		Destination: res.SelectAttrValue("Destination", ""),
+	}
+
+	doc := etree.NewDocument()
+	doc.SetRoot(assertion.Copy())
+	b, err := doc.WriteToBytes()
+	if err != nil {
+		return xmlSAMLRes, fmt.Errorf("could not write SAML assertion: %w", err)
+	}
+
+	if err := xml.Unmarshal(b, &xmlSAMLRes.Assertion); err != nil {
+		return xmlSAMLRes, fmt.Errorf("could not unmarshal SAML assertion: %w", err)
+	}
+
+	return xmlSAMLRes, nil
+}
+
+func (v *Verifier) verifyConditions(a *Assertion) error {
+	if v.Issuer != "" && a.Issuer != v.Issuer {
+		return fmt.Errorf("%w: Issuer %q, want %q", ErrInvalidIssuer, a.Issuer, v.Issuer)
+	}
+
+	audience := v.audience()
+	found := false
+	for _, aud := range a.Audiences {
+		if aud == audience {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return fmt.Errorf("%w: audience %q, want %q", ErrInvalidAudience, a.Audiences, audience)
+	}
+
+	destination := v.destination()
+	if a.Destination == "" && a.Recipient == "" {
+		return fmt.Errorf("%w: no Destination or Recipient", ErrInvalidDestination)
+	}
+	if a.Destination != "" && a.Destination != destination {
+		return fmt.Errorf("%w: Destination %q, want %q", ErrInvalidDestination, a.Destination, destination)
+	}
+	if a.Recipient != "" && a.Recipient != destination {
+		return fmt.Errorf("%w: Recipient %q, want %q", ErrInvalidDestination, a.Recipient, destination)
+	}
+
+	now := v.now()
+	if !a.NotBefore.IsZero() && now.Add(verifyClockSkew).Before(a.NotBefore) {
+		return fmt.Errorf("%w: NotBefore %s", ErrAssertionNotYet, a.NotBefore.Format(time.RFC3339))
+	}
+	for _, notOnOrAfter := range []time.Time{a.NotOnOrAfter, a.SubjectNotOnOrAfter} {
+		if !notOnOrAfter.IsZero() && !now.Add(-verifyClockSkew).Before(notOnOrAfter) {
+			return fmt.Errorf("%w: NotOnOrAfter %s", ErrAssertionExpired, notOnOrAfter.Format(time.RFC3339))
+		}
+	}
+
+	return nil
+}
+
+func (v *Verifier) certificates(ctx context.Context) ([]*x509.Certificate, error) {
+	if len(v.Certificates) > 0 {
+		return v.Certificates, nil
+	}
+	if v.Metadata == "" {
+		return nil, errors.New("IdP certificate to verify SAML assertion is not set")
+	}
+
+	m, err := LoadMetadata(ctx, v.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	v.Certificates = m.Certificates
+
+	return v.Certificates, nil
+}
+
+func (v *Verifier) audience() string {
+	if v.Audience != "" {
+		return v.Audience
+	}
+
+	return AwsSAMLAudience
+}
+
+func (v *Verifier) destination() string {
+	if v.Destination != "" {
+		return v.Destination
+	}
+
+	return AwsSAMLSigninURL
+}
+
+func (v *Verifier) now() time.Time {
+	if v.Now != nil {
+		return v.Now()
+	}
+
+	return time.Now()
+}
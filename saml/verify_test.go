@@ -0,0 +1,249 @@
+package saml_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/beevik/etree"
+	"github.com/google/go-cmp/cmp"
+	dsig "github.com/russellhaering/goxmldsig"
+	"github.com/walkersumida/aws-sso-google/saml"
+)
+
+func TestVerifierVerify(t *testing.T) {
+	t.Parallel()
+
+	key, cert := newTestCertificate(t)
+	_, otherCert := newTestCertificate(t)
+	now := time.Date(2024, 1, 1, 0, 1, 0, 0, time.UTC)
+
+	signedAssertion := signFixture(t, "full.xml", key, cert, false)
+	signedResponse := signFixture(t, "full.xml", key, cert, true)
+
+	tests := map[string]struct {
+		give         string
+		giveVerifier saml.Verifier
+		wantErr      error
+	}{
+		"when assertion is signed": {
+			give:         signedAssertion,
+			giveVerifier: saml.Verifier{Certificates: []*x509.Certificate{cert}},
+		},
+		"when response is signed": {
+			give:         signedResponse,
+			giveVerifier: saml.Verifier{Certificates: []*x509.Certificate{cert}},
+		},
+		"when not signed": {
+			give:         readFixture(t, "full.xml"),
+			giveVerifier: saml.Verifier{Certificates: []*x509.Certificate{cert}},
+			wantErr:      saml.ErrInvalidSignature,
+		},
+		"when assertion is tampered": {
+			give:         tamper(t, signedAssertion, "role/role-b", "role/admin"),
+			giveVerifier: saml.Verifier{Certificates: []*x509.Certificate{cert}},
+			wantErr:      saml.ErrInvalidSignature,
+		},
+		"when response is tampered": {
+			give:         tamper(t, signedResponse, "role/role-b", "role/admin"),
+			giveVerifier: saml.Verifier{Certificates: []*x509.Certificate{cert}},
+			wantErr:      saml.ErrInvalidSignature,
+		},
+		"when signed by another certificate": {
+			give:         signedAssertion,
+			giveVerifier: saml.Verifier{Certificates: []*x509.Certificate{otherCert}},
+			wantErr:      saml.ErrInvalidSignature,
+		},
+		"when issued by another IdP": {
+			give: signedAssertion,
+			giveVerifier: saml.Verifier{
+				Certificates: []*x509.Certificate{cert},
+				Issuer:       "https://accounts.google.com/o/saml2?idpid=YYYYYYYYY",
+			},
+			wantErr: saml.ErrInvalidIssuer,
+		},
+		"when audience is another partition": {
+			give: signedAssertion,
+			giveVerifier: saml.Verifier{
+				Certificates: []*x509.Certificate{cert},
+				Audience:     "urn:amazon:webservices:cn-north-1",
+				Destination:  "https://signin.aws.amazon.com/saml",
+			},
+			wantErr: saml.ErrInvalidAudience,
+		},
+		"when destination is another signin URL": {
+			give: signedAssertion,
+			giveVerifier: saml.Verifier{
+				Certificates: []*x509.Certificate{cert},
+				Destination:  "https://signin.amazonaws.cn/saml",
+			},
+			wantErr: saml.ErrInvalidDestination,
+		},
+		"when assertion is expired": {
+			give: signedAssertion,
+			giveVerifier: saml.Verifier{
+				Certificates: []*x509.Certificate{cert},
+				Now:          func() time.Time { return now.Add(10 * time.Minute) },
+			},
+			wantErr: saml.ErrAssertionExpired,
+		},
+		"when assertion is not yet valid": {
+			give: signedAssertion,
+			giveVerifier: saml.Verifier{
+				Certificates: []*x509.Certificate{cert},
+				Now:          func() time.Time { return now.Add(-10 * time.Minute) },
+			},
+			wantErr: saml.ErrAssertionNotYet,
+		},
+	}
+	for name, tt := range tests {
+		tt, name := tt, name
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			v := tt.giveVerifier
+			if v.Now == nil {
+				v.Now = func() time.Time { return now }
+			}
+			got, err := v.Verify(tt.give)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("want %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			want := []saml.Role{
+				{
+					RoleArn:      "arn:aws:iam::123456789012:role/role-a",
+					PrincipalArn: "arn:aws:iam::123456789012:saml-provider/provider",
+				},
+				{
+					RoleArn:      "arn:aws:iam::123456789012:role/role-b",
+					PrincipalArn: "arn:aws:iam::123456789012:saml-provider/provider",
+				},
+			}
+			if diff := cmp.Diff(want, got.Roles); diff != "" {
+				t.Errorf("mismatch (-want +got): ¥n%s", diff)
+			}
+		})
+	}
+}
+
+func TestVerifierVerifyContextCanceled(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	t.Cleanup(srv.Close)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	v := &saml.Verifier{Metadata: srv.URL}
+	if _, err := v.VerifyContext(ctx, readFixture(t, "full.xml")); !errors.Is(err, context.Canceled) {
+		t.Errorf("want %v, got %v", context.Canceled, err)
+	}
+}
+
+// newTestCertificate returns a self-signed certificate valid in 2024.
+func newTestCertificate(t *testing.T) (*rsa.PrivateKey, *x509.Certificate) {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "Google"},
+		NotBefore:    time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+		NotAfter:     time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return key, cert
+}
+
+// signFixture returns the base64 encoded SAMLResponse in testdata
+// with the assertion, or the whole response, signed.
+func signFixture(t *testing.T, name string, key *rsa.PrivateKey, cert *x509.Certificate, signResponse bool) string {
+	t.Helper()
+
+	b, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := etree.NewDocument()
+	if err := doc.ReadFromBytes(b); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := dsig.NewDefaultSigningContext(dsig.TLSCertKeyStore(tls.Certificate{
+		Certificate: [][]byte{cert.Raw},
+		PrivateKey:  key,
+	}))
+	// Google canonicalizes with exclusive c14n, as the signed assertion
+	// is read apart from the response.
+	ctx.Canonicalizer = dsig.MakeC14N10ExclusiveCanonicalizerWithPrefixList("")
+
+	if signResponse {
+		signed, err := ctx.SignEnveloped(doc.Root())
+		if err != nil {
+			t.Fatal(err)
+		}
+		doc.SetRoot(signed)
+	} else {
+		assertion := doc.Root().SelectElement("Assertion")
+		signed, err := ctx.SignEnveloped(assertion)
+		if err != nil {
+			t.Fatal(err)
+		}
+		doc.Root().InsertChildAt(assertion.Index(), signed)
+		doc.Root().RemoveChild(assertion)
+	}
+
+	b, err = doc.WriteToBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+// tamper replaces old with new in the base64 encoded SAMLResponse.
+func tamper(t *testing.T, samlResponse, old, new string) string {
+	t.Helper()
+
+	b, err := base64.StdEncoding.DecodeString(samlResponse)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return base64.StdEncoding.EncodeToString([]byte(strings.ReplaceAll(string(b), old, new)))
+}
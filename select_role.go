@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/walkersumida/aws-sso-google/picker"
+	"github.com/walkersumida/aws-sso-google/saml"
+	"golang.org/x/term"
+)
+
+// isInteractive reports whether the user can answer prompts.
+// stdout is not checked because it is read by the aws cli.
+func isInteractive() bool {
+	return term.IsTerminal(int(os.Stdin.Fd())) && term.IsTerminal(int(os.Stderr.Fd()))
+}
+
+// newRoleSelector returns a function that lets the user pick a role on the
+// terminal. With --save-role, the chosen role is written to the config file
+// for the profile so that the picker is skipped next time.
+func newRoleSelector(opts *globalOptions) func([]saml.Role) (*saml.Role, error) {
+	return func(roles []saml.Role) (*saml.Role, error) {
+		if len(roles) == 0 {
+			return nil, fmt.Errorf("could not find any role in SAMLResponse")
+		}
+
+		cfg, err := opts.loadConfig()
+		if err != nil {
+			return nil, err
+		}
+
+		labels := make([]string, len(roles))
+		for i, r := range roles {
+			labels[i] = roleLabel(r.RoleArn, cfg.AccountAliases)
+		}
+
+		i, err := picker.Pick("Select a role: ", labels)
+		if err != nil {
+			return nil, err
+		}
+		role := &roles[i]
+
+		if opts.saveRole {
+			p, err := opts.configPath()
+			if err != nil {
+				return nil, err
+			}
+
+			cfg.SetAwsRoleArn(opts.awsProfile, role.RoleArn)
+			if err := cfg.Save(p); err != nil {
+				return nil, err
+			}
+		}
+
+		return role, nil
+	}
+}
+
+// roleLabel returns the role arn prefixed with the account alias if known.
+func roleLabel(roleArn string, aliases map[string]string) string {
+	parts := strings.Split(roleArn, ":")
+	if len(parts) < 5 {
+		return roleArn
+	}
+
+	if alias, ok := aliases[parts[4]]; ok {
+		return fmt.Sprintf("%s (%s)  %s", alias, parts[4], roleArn)
+	}
+
+	return roleArn
+}
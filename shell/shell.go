@@ -0,0 +1,86 @@
+package shell
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+const (
+	Bash       = "bash"
+	Zsh        = "zsh"
+	Fish       = "fish"
+	PowerShell = "powershell"
+)
+
+// IsSupported reports whether statements can be generated for the shell.
+func IsSupported(shell string) bool {
+	switch shell {
+	case Bash, Zsh, Fish, PowerShell:
+		return true
+	default:
+		return false
+	}
+}
+
+// Detect returns the shell of the user from $SHELL,
+// falling back to PowerShell on Windows and bash elsewhere.
+func Detect() string {
+	switch filepath.Base(os.Getenv("SHELL")) {
+	case Zsh:
+		return Zsh
+	case Fish:
+		return Fish
+	case Bash:
+		return Bash
+	}
+
+	if runtime.GOOS == "windows" {
+		return PowerShell
+	}
+
+	return Bash
+}
+
+// Export returns the statement setting the environment variable in the shell.
+func Export(shell, name, value string) (string, error) {
+	switch shell {
+	case Bash, Zsh:
+		return fmt.Sprintf("export %s=%s", name, quotePOSIX(value)), nil
+	case Fish:
+		return fmt.Sprintf("set -gx %s %s", name, quoteFish(value)), nil
+	case PowerShell:
+		return fmt.Sprintf("$Env:%s = %s", name, quotePowerShell(value)), nil
+	default:
+		return "", fmt.Errorf("unknown shell: %s", shell)
+	}
+}
+
+// Unset returns the statement removing the environment variable in the shell.
+func Unset(shell, name string) (string, error) {
+	switch shell {
+	case Bash, Zsh:
+		return fmt.Sprintf("unset %s", name), nil
+	case Fish:
+		return fmt.Sprintf("set -e %s", name), nil
+	case PowerShell:
+		return fmt.Sprintf("Remove-Item Env:%s -ErrorAction SilentlyContinue", name), nil
+	default:
+		return "", fmt.Errorf("unknown shell: %s", shell)
+	}
+}
+
+func quotePOSIX(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func quoteFish(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+	return "'" + r.Replace(s) + "'"
+}
+
+func quotePowerShell(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
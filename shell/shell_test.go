@@ -0,0 +1,96 @@
+package shell_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/walkersumida/aws-sso-google/shell"
+)
+
+func TestExport(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		giveShell string
+		giveValue string
+		want      string
+		wantErr   bool
+	}{
+		"bash": {
+			giveShell: shell.Bash,
+			giveValue: "a'b",
+			want:      `export NAME='a'\''b'`,
+		},
+		"zsh": {
+			giveShell: shell.Zsh,
+			giveValue: "a/b+c=",
+			want:      `export NAME='a/b+c='`,
+		},
+		"fish": {
+			giveShell: shell.Fish,
+			giveValue: `a'b\c`,
+			want:      `set -gx NAME 'a\'b\\c'`,
+		},
+		"powershell": {
+			giveShell: shell.PowerShell,
+			giveValue: "a'b",
+			want:      `$Env:NAME = 'a''b'`,
+		},
+		"unknown": {
+			giveShell: "csh",
+			wantErr:   true,
+		},
+	}
+	for name, tt := range tests {
+		tt, name := tt, name
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := shell.Export(tt.giveShell, "NAME", tt.giveValue)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("mismatch (-want +got): ¥n%s", diff)
+			}
+		})
+	}
+}
+
+func TestUnset(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		giveShell string
+		want      string
+	}{
+		"bash": {
+			giveShell: shell.Bash,
+			want:      "unset NAME",
+		},
+		"fish": {
+			giveShell: shell.Fish,
+			want:      "set -e NAME",
+		},
+		"powershell": {
+			giveShell: shell.PowerShell,
+			want:      "Remove-Item Env:NAME -ErrorAction SilentlyContinue",
+		},
+	}
+	for name, tt := range tests {
+		tt, name := tt, name
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := shell.Unset(tt.giveShell, "NAME")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("mismatch (-want +got): ¥n%s", diff)
+			}
+		})
+	}
+}
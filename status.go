@@ -0,0 +1,201 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/sts/types"
+	"github.com/spf13/cobra"
+	"github.com/walkersumida/aws-sso-google/credential"
+	"github.com/walkersumida/aws-sso-google/partition"
+)
+
+// States of cached credentials.
+const (
+	stateValid    = "valid"
+	stateExpiring = "expiring" // within the refresh skew or min validity, refreshed on next use
+	stateExpired  = "expired"
+	stateInvalid  = "invalid" // rejected by GetCallerIdentity
+)
+
+// sessionStatus is the status of the cached credentials of a profile.
+type sessionStatus struct {
+	Profile     string    `json:"Profile"`
+	Storage     string    `json:"Storage"`
+	RoleArn     string    `json:"RoleArn"`
+	Account     string    `json:"Account"`
+	Expiration  time.Time `json:"Expiration"`
+	Remaining   string    `json:"Remaining"`
+	State       string    `json:"State"`
+	Identity    string    `json:"Identity,omitempty"`
+	VerifyError string    `json:"VerifyError,omitempty"`
+}
+
+func newStatusCmd(opts *globalOptions) *cobra.Command {
+	var output string
+	var verify bool
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show the cached credentials and their remaining lifetime",
+		Long: `Show the cached credentials and their remaining lifetime.
+
+Every profile in the credential file is listed, together with those in the
+encrypted file and the keyring if they have any or the config uses them.
+--aws-profile lists only the profile and the roles of its chain.
+--verify checks the valid credentials with sts:GetCallerIdentity.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if output != "table" && output != "json" {
+				return fmt.Errorf("unknown output format: %s", output)
+			}
+
+			cfg, err := opts.loadConfig()
+			if err != nil {
+				return err
+			}
+			ctx, cancel := opts.context(cmd)
+			defer cancel()
+			now := time.Now()
+			statuses := []sessionStatus{}
+			invalid := 0
+			kinds, err := opts.storedKinds(cfg)
+			if err != nil {
+				return err
+			}
+			for _, kind := range kinds {
+				storage, err := credential.NewStorage(kind)
+				if err != nil {
+					return err
+				}
+
+				names, err := storage.Profiles()
+				if err != nil {
+					return err
+				}
+
+				for _, name := range names {
+					profile, _, _ := strings.Cut(name, "#")
+					if opts.awsProfile != "" && profile != opts.awsProfile {
+						continue
+					}
+
+					v, err := storage.Load(name)
+					if err != nil {
+						return err
+					}
+					if v == nil {
+						continue
+					}
+
+					p := opts.resolveNamedProfile(cfg, profile)
+					// The same margin as Credential.IsExpired refreshes within.
+					st := newSessionStatus(name, v, now, max(p.RefreshSkew, p.MinValidity))
+					st.Storage = kind
+
+					if verify && st.State != stateExpired {
+						part, err := partition.FromArn(v.RoleArn)
+						if err != nil {
+							part, err = resolvePartition(p)
+							if err != nil {
+								return err
+							}
+						}
+
+						st.Identity, err = newSTS(p, "", part).GetCallerIdentityContext(ctx, &types.Credentials{
+							AccessKeyId:     &v.AccessKeyID,
+							SecretAccessKey: &v.SecretAccessKey,
+							SessionToken:    &v.SessionToken,
+						})
+						if err != nil {
+							if ctx.Err() != nil {
+								return ctx.Err()
+							}
+							invalid++
+							st.State = stateInvalid
+							st.VerifyError = err.Error()
+							_, _ = fmt.Fprintf(os.Stderr, "%s: %v\n", name, err)
+						}
+					}
+
+					statuses = append(statuses, st)
+				}
+			}
+
+			if err := printStatus(os.Stdout, statuses, output); err != nil {
+				return err
+			}
+			if invalid > 0 {
+				return fmt.Errorf("could not verify %d of %d profiles", invalid, len(statuses))
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&output, "output", "o", "table", "Output format (table, json)")
+	cmd.Flags().BoolVar(&verify, "verify", false, "Verify the credentials with sts:GetCallerIdentity")
+
+	return cmd
+}
+
+// newSessionStatus returns the status of the credentials at now.
+// Credentials expiring within margin are refreshed on next use.
+func newSessionStatus(name string, v *credential.Value, now time.Time, margin time.Duration) sessionStatus {
+	st := sessionStatus{
+		Profile:    name,
+		RoleArn:    v.RoleArn,
+		Expiration: v.Expiration,
+		Remaining:  max(v.Expiration.Sub(now), 0).Truncate(time.Second).String(),
+	}
+	if parts := strings.Split(v.RoleArn, ":"); len(parts) > 4 {
+		st.Account = parts[4]
+	}
+
+	switch {
+	case !now.Before(v.Expiration):
+		st.State = stateExpired
+	case !now.Add(margin).Before(v.Expiration):
+		st.State = stateExpiring
+	default:
+		st.State = stateValid
+	}
+
+	return st
+}
+
+func printStatus(w io.Writer, statuses []sessionStatus, output string) error {
+	switch output {
+	case "json":
+		b, err := json.MarshalIndent(statuses, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(b))
+		return err
+	case "table":
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(tw, "PROFILE\tSTORAGE\tROLE ARN\tACCOUNT\tEXPIRATION\tREMAINING\tSTATE")
+		for _, st := range statuses {
+			_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+				st.Profile, st.Storage, orDash(st.RoleArn), orDash(st.Account),
+				st.Expiration.Local().Format(time.RFC3339), st.Remaining, st.State)
+		}
+		return tw.Flush()
+	default:
+		return fmt.Errorf("unknown output format: %s", output)
+	}
+}
+
+// orDash returns "-" for values unknown for credentials saved by older versions.
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+
+	return s
+}
@@ -4,6 +4,8 @@
 package mock
 
 import (
+	"context"
+	"github.com/aws/aws-sdk-go-v2/service/sts/types"
 	"github.com/walkersumida/aws-sso-google/sts"
 	"sync"
 )
@@ -18,15 +20,30 @@ var _ sts.STSer = &STSerMock{}
 //
 //		// make and configure a mocked sts.STSer
 //		mockedSTSer := &STSerMock{
+//			AssumeRoleFunc: func(hop sts.Hop, credentials *types.Credentials) (*types.Credentials, error) {
+//				panic("mock out the AssumeRole method")
+//			},
+//			AssumeRoleContextFunc: func(contextMoqParam context.Context, hop sts.Hop, credentials *types.Credentials) (*types.Credentials, error) {
+//				panic("mock out the AssumeRoleContext method")
+//			},
 //			AssumeRoleWithSAMLFunc: func() (*sts.Response, error) {
 //				panic("mock out the AssumeRoleWithSAML method")
 //			},
+//			AssumeRoleWithSAMLContextFunc: func(contextMoqParam context.Context) (*sts.Response, error) {
+//				panic("mock out the AssumeRoleWithSAMLContext method")
+//			},
 //			SetAwsPrincipalArnFunc: func(s string)  {
 //				panic("mock out the SetAwsPrincipalArn method")
 //			},
+//			SetAwsRoleArnFunc: func(s string)  {
+//				panic("mock out the SetAwsRoleArn method")
+//			},
 //			SetSAMLAssertionFunc: func(s string)  {
 //				panic("mock out the SetSAMLAssertion method")
 //			},
+//			SetSAMLSessionDurationFunc: func(n int32)  {
+//				panic("mock out the SetSAMLSessionDuration method")
+//			},
 //		}
 //
 //		// use mockedSTSer in code that requires sts.STSer
@@ -34,34 +51,161 @@ var _ sts.STSer = &STSerMock{}
 //
 //	}
 type STSerMock struct {
+	// AssumeRoleFunc mocks the AssumeRole method.
+	AssumeRoleFunc func(hop sts.Hop, credentials *types.Credentials) (*types.Credentials, error)
+
+	// AssumeRoleContextFunc mocks the AssumeRoleContext method.
+	AssumeRoleContextFunc func(contextMoqParam context.Context, hop sts.Hop, credentials *types.Credentials) (*types.Credentials, error)
+
 	// AssumeRoleWithSAMLFunc mocks the AssumeRoleWithSAML method.
 	AssumeRoleWithSAMLFunc func() (*sts.Response, error)
 
+	// AssumeRoleWithSAMLContextFunc mocks the AssumeRoleWithSAMLContext method.
+	AssumeRoleWithSAMLContextFunc func(contextMoqParam context.Context) (*sts.Response, error)
+
 	// SetAwsPrincipalArnFunc mocks the SetAwsPrincipalArn method.
 	SetAwsPrincipalArnFunc func(s string)
 
+	// SetAwsRoleArnFunc mocks the SetAwsRoleArn method.
+	SetAwsRoleArnFunc func(s string)
+
 	// SetSAMLAssertionFunc mocks the SetSAMLAssertion method.
 	SetSAMLAssertionFunc func(s string)
 
+	// SetSAMLSessionDurationFunc mocks the SetSAMLSessionDuration method.
+	SetSAMLSessionDurationFunc func(n int32)
+
 	// calls tracks calls to the methods.
 	calls struct {
+		// AssumeRole holds details about calls to the AssumeRole method.
+		AssumeRole []struct {
+			// Hop is the hop argument value.
+			Hop sts.Hop
+			// Credentials is the credentials argument value.
+			Credentials *types.Credentials
+		}
+		// AssumeRoleContext holds details about calls to the AssumeRoleContext method.
+		AssumeRoleContext []struct {
+			// ContextMoqParam is the contextMoqParam argument value.
+			ContextMoqParam context.Context
+			// Hop is the hop argument value.
+			Hop sts.Hop
+			// Credentials is the credentials argument value.
+			Credentials *types.Credentials
+		}
 		// AssumeRoleWithSAML holds details about calls to the AssumeRoleWithSAML method.
 		AssumeRoleWithSAML []struct {
 		}
+		// AssumeRoleWithSAMLContext holds details about calls to the AssumeRoleWithSAMLContext method.
+		AssumeRoleWithSAMLContext []struct {
+			// ContextMoqParam is the contextMoqParam argument value.
+			ContextMoqParam context.Context
+		}
 		// SetAwsPrincipalArn holds details about calls to the SetAwsPrincipalArn method.
 		SetAwsPrincipalArn []struct {
 			// S is the s argument value.
 			S string
 		}
+		// SetAwsRoleArn holds details about calls to the SetAwsRoleArn method.
+		SetAwsRoleArn []struct {
+			// S is the s argument value.
+			S string
+		}
 		// SetSAMLAssertion holds details about calls to the SetSAMLAssertion method.
 		SetSAMLAssertion []struct {
 			// S is the s argument value.
 			S string
 		}
+		// SetSAMLSessionDuration holds details about calls to the SetSAMLSessionDuration method.
+		SetSAMLSessionDuration []struct {
+			// N is the n argument value.
+			N int32
+		}
+	}
+	lockAssumeRole                sync.RWMutex
+	lockAssumeRoleContext         sync.RWMutex
+	lockAssumeRoleWithSAML        sync.RWMutex
+	lockAssumeRoleWithSAMLContext sync.RWMutex
+	lockSetAwsPrincipalArn        sync.RWMutex
+	lockSetAwsRoleArn             sync.RWMutex
+	lockSetSAMLAssertion          sync.RWMutex
+	lockSetSAMLSessionDuration    sync.RWMutex
+}
+
+// AssumeRole calls AssumeRoleFunc.
+func (mock *STSerMock) AssumeRole(hop sts.Hop, credentials *types.Credentials) (*types.Credentials, error) {
+	if mock.AssumeRoleFunc == nil {
+		panic("STSerMock.AssumeRoleFunc: method is nil but STSer.AssumeRole was just called")
+	}
+	callInfo := struct {
+		Hop         sts.Hop
+		Credentials *types.Credentials
+	}{
+		Hop:         hop,
+		Credentials: credentials,
 	}
-	lockAssumeRoleWithSAML sync.RWMutex
-	lockSetAwsPrincipalArn sync.RWMutex
-	lockSetSAMLAssertion   sync.RWMutex
+	mock.lockAssumeRole.Lock()
+	mock.calls.AssumeRole = append(mock.calls.AssumeRole, callInfo)
+	mock.lockAssumeRole.Unlock()
+	return mock.AssumeRoleFunc(hop, credentials)
+}
+
+// AssumeRoleCalls gets all the calls that were made to AssumeRole.
+// Check the length with:
+//
+//	len(mockedSTSer.AssumeRoleCalls())
+func (mock *STSerMock) AssumeRoleCalls() []struct {
+	Hop         sts.Hop
+	Credentials *types.Credentials
+} {
+	var calls []struct {
+		Hop         sts.Hop
+		Credentials *types.Credentials
+	}
+	mock.lockAssumeRole.RLock()
+	calls = mock.calls.AssumeRole
+	mock.lockAssumeRole.RUnlock()
+	return calls
+}
+
+// AssumeRoleContext calls AssumeRoleContextFunc.
+func (mock *STSerMock) AssumeRoleContext(contextMoqParam context.Context, hop sts.Hop, credentials *types.Credentials) (*types.Credentials, error) {
+	if mock.AssumeRoleContextFunc == nil {
+		panic("STSerMock.AssumeRoleContextFunc: method is nil but STSer.AssumeRoleContext was just called")
+	}
+	callInfo := struct {
+		ContextMoqParam context.Context
+		Hop             sts.Hop
+		Credentials     *types.Credentials
+	}{
+		ContextMoqParam: contextMoqParam,
+		Hop:             hop,
+		Credentials:     credentials,
+	}
+	mock.lockAssumeRoleContext.Lock()
+	mock.calls.AssumeRoleContext = append(mock.calls.AssumeRoleContext, callInfo)
+	mock.lockAssumeRoleContext.Unlock()
+	return mock.AssumeRoleContextFunc(contextMoqParam, hop, credentials)
+}
+
+// AssumeRoleContextCalls gets all the calls that were made to AssumeRoleContext.
+// Check the length with:
+//
+//	len(mockedSTSer.AssumeRoleContextCalls())
+func (mock *STSerMock) AssumeRoleContextCalls() []struct {
+	ContextMoqParam context.Context
+	Hop             sts.Hop
+	Credentials     *types.Credentials
+} {
+	var calls []struct {
+		ContextMoqParam context.Context
+		Hop             sts.Hop
+		Credentials     *types.Credentials
+	}
+	mock.lockAssumeRoleContext.RLock()
+	calls = mock.calls.AssumeRoleContext
+	mock.lockAssumeRoleContext.RUnlock()
+	return calls
 }
 
 // AssumeRoleWithSAML calls AssumeRoleWithSAMLFunc.
@@ -91,6 +235,38 @@ func (mock *STSerMock) AssumeRoleWithSAMLCalls() []struct {
 	return calls
 }
 
+// AssumeRoleWithSAMLContext calls AssumeRoleWithSAMLContextFunc.
+func (mock *STSerMock) AssumeRoleWithSAMLContext(contextMoqParam context.Context) (*sts.Response, error) {
+	if mock.AssumeRoleWithSAMLContextFunc == nil {
+		panic("STSerMock.AssumeRoleWithSAMLContextFunc: method is nil but STSer.AssumeRoleWithSAMLContext was just called")
+	}
+	callInfo := struct {
+		ContextMoqParam context.Context
+	}{
+		ContextMoqParam: contextMoqParam,
+	}
+	mock.lockAssumeRoleWithSAMLContext.Lock()
+	mock.calls.AssumeRoleWithSAMLContext = append(mock.calls.AssumeRoleWithSAMLContext, callInfo)
+	mock.lockAssumeRoleWithSAMLContext.Unlock()
+	return mock.AssumeRoleWithSAMLContextFunc(contextMoqParam)
+}
+
+// AssumeRoleWithSAMLContextCalls gets all the calls that were made to AssumeRoleWithSAMLContext.
+// Check the length with:
+//
+//	len(mockedSTSer.AssumeRoleWithSAMLContextCalls())
+func (mock *STSerMock) AssumeRoleWithSAMLContextCalls() []struct {
+	ContextMoqParam context.Context
+} {
+	var calls []struct {
+		ContextMoqParam context.Context
+	}
+	mock.lockAssumeRoleWithSAMLContext.RLock()
+	calls = mock.calls.AssumeRoleWithSAMLContext
+	mock.lockAssumeRoleWithSAMLContext.RUnlock()
+	return calls
+}
+
 // SetAwsPrincipalArn calls SetAwsPrincipalArnFunc.
 func (mock *STSerMock) SetAwsPrincipalArn(s string) {
 	if mock.SetAwsPrincipalArnFunc == nil {
@@ -123,6 +299,38 @@ func (mock *STSerMock) SetAwsPrincipalArnCalls() []struct {
 	return calls
 }
 
+// SetAwsRoleArn calls SetAwsRoleArnFunc.
+func (mock *STSerMock) SetAwsRoleArn(s string) {
+	if mock.SetAwsRoleArnFunc == nil {
+		panic("STSerMock.SetAwsRoleArnFunc: method is nil but STSer.SetAwsRoleArn was just called")
+	}
+	callInfo := struct {
+		S string
+	}{
+		S: s,
+	}
+	mock.lockSetAwsRoleArn.Lock()
+	mock.calls.SetAwsRoleArn = append(mock.calls.SetAwsRoleArn, callInfo)
+	mock.lockSetAwsRoleArn.Unlock()
+	mock.SetAwsRoleArnFunc(s)
+}
+
+// SetAwsRoleArnCalls gets all the calls that were made to SetAwsRoleArn.
+// Check the length with:
+//
+//	len(mockedSTSer.SetAwsRoleArnCalls())
+func (mock *STSerMock) SetAwsRoleArnCalls() []struct {
+	S string
+} {
+	var calls []struct {
+		S string
+	}
+	mock.lockSetAwsRoleArn.RLock()
+	calls = mock.calls.SetAwsRoleArn
+	mock.lockSetAwsRoleArn.RUnlock()
+	return calls
+}
+
 // SetSAMLAssertion calls SetSAMLAssertionFunc.
 func (mock *STSerMock) SetSAMLAssertion(s string) {
 	if mock.SetSAMLAssertionFunc == nil {
@@ -154,3 +362,35 @@ func (mock *STSerMock) SetSAMLAssertionCalls() []struct {
 	mock.lockSetSAMLAssertion.RUnlock()
 	return calls
 }
+
+// SetSAMLSessionDuration calls SetSAMLSessionDurationFunc.
+func (mock *STSerMock) SetSAMLSessionDuration(n int32) {
+	if mock.SetSAMLSessionDurationFunc == nil {
+		panic("STSerMock.SetSAMLSessionDurationFunc: method is nil but STSer.SetSAMLSessionDuration was just called")
+	}
+	callInfo := struct {
+		N int32
+	}{
+		N: n,
+	}
+	mock.lockSetSAMLSessionDuration.Lock()
+	mock.calls.SetSAMLSessionDuration = append(mock.calls.SetSAMLSessionDuration, callInfo)
+	mock.lockSetSAMLSessionDuration.Unlock()
+	mock.SetSAMLSessionDurationFunc(n)
+}
+
+// SetSAMLSessionDurationCalls gets all the calls that were made to SetSAMLSessionDuration.
+// Check the length with:
+//
+//	len(mockedSTSer.SetSAMLSessionDurationCalls())
+func (mock *STSerMock) SetSAMLSessionDurationCalls() []struct {
+	N int32
+} {
+	var calls []struct {
+		N int32
+	}
+	mock.lockSetSAMLSessionDuration.RLock()
+	calls = mock.calls.SetSAMLSessionDuration
+	mock.lockSetSAMLSessionDuration.RUnlock()
+	return calls
+}
@@ -2,25 +2,42 @@ package sts
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
 	sdksts "github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/aws/aws-sdk-go-v2/service/sts/types"
+	"github.com/aws/smithy-go"
 )
 
 type STSer interface {
+	AssumeRole(Hop, *types.Credentials) (*types.Credentials, error)
+	AssumeRoleContext(context.Context, Hop, *types.Credentials) (*types.Credentials, error)
 	AssumeRoleWithSAML() (*Response, error)
+	AssumeRoleWithSAMLContext(context.Context) (*Response, error)
 	SetAwsPrincipalArn(string)
+	SetAwsRoleArn(string)
 	SetSAMLAssertion(string)
+	SetSAMLSessionDuration(int32)
 }
 
 type STS struct {
 	AwsProfile         string
 	AwsRegion          string
 	AwsRoleArn         string
-	AwsSessionDuration int32
+	AwsSessionDuration int32 // SAMLSessionDuration or DefaultSessionDuration if zero
 	AwsPrincipalArn    string
 	SAMLAssertion      string
+
+	// SAMLSessionDuration is the SessionDuration attribute of the SAML assertion.
+	SAMLSessionDuration int32
+
+	// DefaultRegion is the region used when neither AwsRegion nor the
+	// shared config sets one, so that the STS endpoint is in the partition.
+	DefaultRegion string
 }
 
 var _ STSer = &STS{}
@@ -29,6 +46,23 @@ type Response struct {
 	sdksts.AssumeRoleWithSAMLOutput
 }
 
+// Hop is a role assumed with the credentials of the previous role
+// in a role chain.
+type Hop struct {
+	RoleArn     string
+	ExternalID  string
+	SessionName string // DefaultRoleSessionName if empty
+}
+
+const (
+	DefaultRoleSessionName = "aws-sso-google"
+
+	// Session durations in seconds accepted by AssumeRoleWithSAML.
+	DefaultSessionDuration = 3600
+	MinSessionDuration     = 900
+	MaxSessionDuration     = 43200
+)
+
 func New(profile, region, roleArn string, duration int32) *STS {
 	return &STS{
 		AwsProfile:         profile,
@@ -42,40 +76,162 @@ func (s *STS) SetAwsPrincipalArn(principalArn string) {
 	s.AwsPrincipalArn = principalArn
 }
 
+func (s *STS) SetAwsRoleArn(roleArn string) {
+	s.AwsRoleArn = roleArn
+}
+
 func (s *STS) SetSAMLAssertion(samlAssertion string) {
 	s.SAMLAssertion = samlAssertion
 }
 
+func (s *STS) SetSAMLSessionDuration(duration int32) {
+	s.SAMLSessionDuration = duration
+}
+
 func (s *STS) AssumeRoleWithSAML() (*Response, error) {
+	return s.AssumeRoleWithSAMLContext(context.Background())
+}
+
+func (s *STS) AssumeRoleWithSAMLContext(ctx context.Context) (*Response, error) {
+	stsCli, err := s.newClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	duration := s.sessionDuration()
+	for {
+		input := &sdksts.AssumeRoleWithSAMLInput{
+			DurationSeconds: &duration,
+			PrincipalArn:    &s.AwsPrincipalArn,
+			RoleArn:         &s.AwsRoleArn,
+			SAMLAssertion:   &s.SAMLAssertion,
+		}
+
+		output, err := stsCli.AssumeRoleWithSAML(ctx, input)
+		if isDurationTooLong(err) && duration > DefaultSessionDuration {
+			// The maximum of the role is unknown, so try the whole hours
+			// below until it is accepted. One hour is always allowed.
+			duration = lowerSessionDuration(duration)
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("could not assume role with SAML: %w", err)
+		}
+
+		return &Response{AssumeRoleWithSAMLOutput: *output}, nil
+	}
+}
+
+// AssumeRole assumes the role of the hop with the source credentials.
+func (s *STS) AssumeRole(hop Hop, source *types.Credentials) (*types.Credentials, error) {
+	return s.AssumeRoleContext(context.Background(), hop, source)
+}
+
+func (s *STS) AssumeRoleContext(ctx context.Context, hop Hop, source *types.Credentials) (*types.Credentials, error) {
+	stsCli, err := s.newClient(ctx, config.WithCredentialsProvider(
+		credentials.NewStaticCredentialsProvider(*source.AccessKeyId, *source.SecretAccessKey, *source.SessionToken),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	sessionName := hop.SessionName
+	if sessionName == "" {
+		sessionName = DefaultRoleSessionName
+	}
+
+	input := &sdksts.AssumeRoleInput{
+		RoleArn:         &hop.RoleArn,
+		RoleSessionName: &sessionName,
+	}
+	if hop.ExternalID != "" {
+		input.ExternalId = &hop.ExternalID
+	}
+
+	output, err := stsCli.AssumeRole(ctx, input)
+	if err != nil {
+		return nil, fmt.Errorf("could not assume role %s: %w", hop.RoleArn, err)
+	}
+
+	return output.Credentials, nil
+}
+
+// GetCallerIdentityContext returns the arn of the identity of the credentials,
+// which fails if the credentials are expired or revoked.
+func (s *STS) GetCallerIdentityContext(ctx context.Context, c *types.Credentials) (string, error) {
+	stsCli, err := s.newClient(ctx, config.WithCredentialsProvider(
+		credentials.NewStaticCredentialsProvider(*c.AccessKeyId, *c.SecretAccessKey, *c.SessionToken),
+	))
+	if err != nil {
+		return "", err
+	}
+
+	output, err := stsCli.GetCallerIdentity(ctx, &sdksts.GetCallerIdentityInput{})
+	if err != nil {
+		return "", fmt.Errorf("could not get caller identity: %w", err)
+	}
+	if output.Arn == nil {
+		return "", errors.New("could not find arn in caller identity")
+	}
+
+	return *output.Arn, nil
+}
+
+// sessionDuration returns the duration to request, clamped to the range
+// accepted by AssumeRoleWithSAML.
+func (s *STS) sessionDuration() int32 {
+	d := s.AwsSessionDuration
+	if d == 0 {
+		d = s.SAMLSessionDuration
+	}
+	if d == 0 {
+		d = DefaultSessionDuration
+	}
+
+	return min(max(d, MinSessionDuration), MaxSessionDuration)
+}
+
+// lowerSessionDuration returns the largest whole hour below d.
+func lowerSessionDuration(d int32) int32 {
+	return max((d-1)/3600*3600, DefaultSessionDuration)
+}
+
+// isDurationTooLong reports whether err is returned because the requested
+// duration exceeds the MaxSessionDuration of the role.
+func isDurationTooLong(err error) bool {
+	var apiErr smithy.APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+
+	return apiErr.ErrorCode() == "ValidationError" && strings.Contains(apiErr.ErrorMessage(), "DurationSeconds")
+}
+
+func (s *STS) newClient(ctx context.Context, optFns ...func(*config.LoadOptions) error) (*sdksts.Client, error) {
 	opts := []func(*config.LoadOptions) error{
 		config.WithSharedConfigProfile(s.AwsProfile),
 	}
 	if s.AwsRegion != "" {
 		opts = append(opts, config.WithRegion(s.AwsRegion))
 	}
+	opts = append(opts, optFns...)
 
-	ctx := context.Background()
 	cfg, err := config.LoadDefaultConfig(
 		ctx,
 		opts...,
 	)
+	// Profiles may be only in the config file of this tool,
+	// in which case the shared config is not needed.
+	var notExist config.SharedConfigProfileNotExistError
+	if errors.As(err, &notExist) {
+		cfg, err = config.LoadDefaultConfig(ctx, opts[1:]...)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("could not load default config: %w", err)
 	}
-
-	stsCli := sdksts.NewFromConfig(cfg)
-
-	input := &sdksts.AssumeRoleWithSAMLInput{
-		DurationSeconds: &s.AwsSessionDuration,
-		PrincipalArn:    &s.AwsPrincipalArn,
-		RoleArn:         &s.AwsRoleArn,
-		SAMLAssertion:   &s.SAMLAssertion,
-	}
-
-	output, err := stsCli.AssumeRoleWithSAML(ctx, input)
-	if err != nil {
-		return nil, fmt.Errorf("could not assume role with SAML: %w", err)
+	if cfg.Region == "" {
+		cfg.Region = s.DefaultRegion
 	}
 
-	return &Response{AssumeRoleWithSAMLOutput: *output}, nil
+	return sdksts.NewFromConfig(cfg), nil
 }
@@ -0,0 +1,172 @@
+package sts_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/sts/types"
+	"github.com/google/go-cmp/cmp"
+	"github.com/walkersumida/aws-sso-google/sts"
+)
+
+const (
+	validationErrorResponse = `<ErrorResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">
+  <Error>
+    <Type>Sender</Type>
+    <Code>ValidationError</Code>
+    <Message>The requested DurationSeconds exceeds the MaxSessionDuration set for this role.</Message>
+  </Error>
+  <RequestId>request-id</RequestId>
+</ErrorResponse>`
+
+	assumeRoleWithSAMLResponse = `<AssumeRoleWithSAMLResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">
+  <AssumeRoleWithSAMLResult>
+    <Credentials>
+      <AccessKeyId>access-key-id</AccessKeyId>
+      <SecretAccessKey>secret-access-key</SecretAccessKey>
+      <SessionToken>session-token</SessionToken>
+      <Expiration>2024-01-01T00:00:00Z</Expiration>
+    </Credentials>
+  </AssumeRoleWithSAMLResult>
+</AssumeRoleWithSAMLResponse>`
+
+	getCallerIdentityResponse = `<GetCallerIdentityResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/">
+  <GetCallerIdentityResult>
+    <Arn>arn:aws:sts::123456789012:assumed-role/role-a/user@example.com</Arn>
+    <UserId>AROAEXAMPLE:user@example.com</UserId>
+    <Account>123456789012</Account>
+  </GetCallerIdentityResult>
+</GetCallerIdentityResponse>`
+)
+
+func TestAssumeRoleWithSAMLSessionDuration(t *testing.T) {
+	tests := map[string]struct {
+		giveDuration     int32
+		giveSAMLDuration int32
+		giveMaxDuration  int
+		wantDurations    []string
+	}{
+		"when nothing is set": {
+			giveMaxDuration: 43200,
+			wantDurations:   []string{"3600"},
+		},
+		"when the assertion sets the duration": {
+			giveSAMLDuration: 28800,
+			giveMaxDuration:  43200,
+			wantDurations:    []string{"28800"},
+		},
+		"when the flag overrides the assertion": {
+			giveDuration:     7200,
+			giveSAMLDuration: 28800,
+			giveMaxDuration:  43200,
+			wantDurations:    []string{"7200"},
+		},
+		"when the duration is out of range": {
+			giveDuration:    86400,
+			giveMaxDuration: 43200,
+			wantDurations:   []string{"43200"},
+		},
+		"when the duration exceeds the maximum of the role": {
+			giveSAMLDuration: 28800,
+			giveMaxDuration:  14400,
+			wantDurations:    []string{"28800", "25200", "21600", "18000", "14400"},
+		},
+		"when the duration is not a whole hour": {
+			giveDuration:    5400,
+			giveMaxDuration: 3600,
+			wantDurations:   []string{"5400", "3600"},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var gotDurations []string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if err := r.ParseForm(); err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				d := r.PostForm.Get("DurationSeconds")
+				gotDurations = append(gotDurations, d)
+
+				w.Header().Set("Content-Type", "text/xml")
+				if n, _ := strconv.Atoi(d); n > tt.giveMaxDuration {
+					w.WriteHeader(http.StatusBadRequest)
+					_, _ = fmt.Fprint(w, validationErrorResponse)
+					return
+				}
+				_, _ = fmt.Fprint(w, assumeRoleWithSAMLResponse)
+			}))
+			defer srv.Close()
+
+			t.Setenv("AWS_CONFIG_FILE", filepath.Join(t.TempDir(), "config"))
+			t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(t.TempDir(), "credentials"))
+			t.Setenv("AWS_ENDPOINT_URL_STS", srv.URL)
+
+			s := sts.New("", "us-east-1", "arn:aws:iam::123456789012:role/role-a", tt.giveDuration)
+			s.SetAwsPrincipalArn("arn:aws:iam::123456789012:saml-provider/provider")
+			s.SetSAMLAssertion("saml")
+			s.SetSAMLSessionDuration(tt.giveSAMLDuration)
+
+			res, err := s.AssumeRoleWithSAML()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if diff := cmp.Diff("access-key-id", *res.Credentials.AccessKeyId); diff != "" {
+				t.Errorf("mismatch (-want +got): ¥n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantDurations, gotDurations); diff != "" {
+				t.Errorf("mismatch (-want +got): ¥n%s", diff)
+			}
+		})
+	}
+}
+
+func TestGetCallerIdentity(t *testing.T) {
+	var gotAction string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		gotAction = r.PostForm.Get("Action")
+
+		w.Header().Set("Content-Type", "text/xml")
+		_, _ = fmt.Fprint(w, getCallerIdentityResponse)
+	}))
+	defer srv.Close()
+
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(t.TempDir(), "config"))
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(t.TempDir(), "credentials"))
+	t.Setenv("AWS_ENDPOINT_URL_STS", srv.URL)
+
+	tests := map[string]string{
+		"when profile is not set":                  "",
+		"when profile is not in the shared config": "only-in-config-file",
+	}
+	for name, profile := range tests {
+		t.Run(name, func(t *testing.T) {
+			gotAction = ""
+			accessKeyID, secretAccessKey, sessionToken := "access-key-id", "secret-access-key", "session-token"
+			s := sts.New(profile, "us-east-1", "", 0)
+			got, err := s.GetCallerIdentityContext(context.Background(), &types.Credentials{
+				AccessKeyId:     &accessKeyID,
+				SecretAccessKey: &secretAccessKey,
+				SessionToken:    &sessionToken,
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if diff := cmp.Diff("arn:aws:sts::123456789012:assumed-role/role-a/user@example.com", got); diff != "" {
+				t.Errorf("mismatch (-want +got): ¥n%s", diff)
+			}
+			if diff := cmp.Diff("GetCallerIdentity", gotAction); diff != "" {
+				t.Errorf("mismatch (-want +got): ¥n%s", diff)
+			}
+		})
+	}
+}